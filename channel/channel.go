@@ -0,0 +1,115 @@
+// Файл: channel/channel.go
+package channel
+
+import (
+	"drone_trust_sim/config"
+	"fmt"
+	"math"
+)
+
+// Model - модель радиоканала: отображает расстояние между узлами
+// в вероятность успешного приема пакета.
+type Model interface {
+	ReceptionProbability(distance float64) float64
+}
+
+// Названия моделей в SimulatorConfig.ChannelModel
+const (
+	UnitDisk    = "UnitDisk"    // Исходная модель: идеальная связь внутри CommunicationRadius
+	FreeSpace   = "FreeSpace"   // Потери Фрииса, прием при средней мощности не ниже чувствительности
+	LogDistance = "LogDistance" // Логарифмические потери с лог-нормальным затенением
+	Rayleigh    = "Rayleigh"    // Логарифмические потери и релеевские замирания
+	Rician      = "Rician"      // Логарифмические потери и райсовские замирания с K-фактором
+)
+
+// Known сообщает, существует ли модель с таким названием
+func Known(name string) bool {
+	switch name {
+	case UnitDisk, FreeSpace, LogDistance, Rayleigh, Rician:
+		return true
+	}
+	return false
+}
+
+// New создает модель канала, выбранную в конфигурации
+func New(cfg *config.SimulatorConfig) (Model, error) {
+	if cfg.ChannelModel == UnitDisk {
+		return &UnitDiskModel{radius: cfg.CommunicationRadius}, nil
+	}
+
+	exponent := cfg.PathLossExponent
+	if cfg.ChannelModel == FreeSpace {
+		exponent = 2
+	}
+	b := budget{
+		txPower:     cfg.TxPower,
+		refDistance: cfg.ReferenceDistance,
+		refLoss:     freeSpaceLoss(cfg.ReferenceDistance, cfg.Frequency),
+		exponent:    exponent,
+		sensitivity: cfg.RxSensitivity,
+	}
+	// Без явной чувствительности калибруем канал так, чтобы средняя мощность на границе
+	// CommunicationRadius совпадала с порогом: радиус сохраняет смысл дальности связи
+	if b.sensitivity == 0 {
+		b.sensitivity = b.meanPower(cfg.CommunicationRadius)
+	}
+
+	switch cfg.ChannelModel {
+	case FreeSpace:
+		return &FreeSpaceModel{budget: b}, nil
+	case LogDistance:
+		return &ShadowingModel{budget: b, sigma: cfg.ShadowingSigma}, nil
+	case Rayleigh:
+		return &RicianModel{budget: b, k: 0}, nil
+	case Rician:
+		return &RicianModel{budget: b, k: cfg.RicianK}, nil
+	}
+	return nil, fmt.Errorf("неизвестная модель канала: %q", cfg.ChannelModel)
+}
+
+// Range возвращает радиус поиска соседей: расстояние, за которым вероятность приема
+// модели m ниже minQuality. Все модели монотонно убывают с расстоянием, поэтому граница
+// находится делением пополам, начиная с оценки hint; результат не меньше истинной границы.
+// Если вероятность не опускается ниже порога ни на каком разумном расстоянии, возвращается +Inf.
+func Range(m Model, minQuality, hint float64) float64 {
+	hi := max(hint, 1)
+	for m.ReceptionProbability(hi) >= minQuality {
+		hi *= 2
+		if hi > 1e12 {
+			return math.Inf(1)
+		}
+	}
+	lo := 0.0
+	for range 60 {
+		mid := (lo + hi) / 2
+		if m.ReceptionProbability(mid) >= minQuality {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// budget - энергетический бюджет линии: мощность передатчика, потери на трассе
+// и чувствительность приемника (все в дБ/дБм)
+type budget struct {
+	txPower     float64
+	refDistance float64
+	refLoss     float64 // Потери в свободном пространстве на опорном расстоянии
+	exponent    float64
+	sensitivity float64
+}
+
+// meanPower - средняя мощность на входе приемника, дБм
+func (b *budget) meanPower(distance float64) float64 {
+	// Ближе опорного расстояния потери не уменьшаются (дальняя зона антенны)
+	d := math.Max(distance, b.refDistance)
+	return b.txPower - b.refLoss - 10*b.exponent*math.Log10(d/b.refDistance)
+}
+
+// freeSpaceLoss - потери Фрииса в дБ для расстояния в метрах и частоты в Гц
+func freeSpaceLoss(distance, frequency float64) float64 {
+	const speedOfLight = 299792458.0
+	return 20 * math.Log10(4*math.Pi*distance*frequency/speedOfLight)
+}
@@ -0,0 +1,89 @@
+// Файл: channel/models.go
+package channel
+
+import "math"
+
+// UnitDiskModel - связь есть только внутри радиуса и всегда надежна
+type UnitDiskModel struct {
+	radius float64
+}
+
+func (m *UnitDiskModel) ReceptionProbability(distance float64) float64 {
+	if distance <= m.radius {
+		return 1
+	}
+	return 0
+}
+
+// FreeSpaceModel - детерминированный прием по модели Фрииса:
+// пакет принимается, если мощность не ниже чувствительности
+type FreeSpaceModel struct {
+	budget
+}
+
+func (m *FreeSpaceModel) ReceptionProbability(distance float64) float64 {
+	if m.meanPower(distance) >= m.sensitivity {
+		return 1
+	}
+	return 0
+}
+
+// ShadowingModel - логарифмические потери плюс гауссово затенение с СКО sigma дБ.
+// Вероятность приема - доля реализаций затенения, при которых мощность выше порога.
+type ShadowingModel struct {
+	budget
+	sigma float64
+}
+
+func (m *ShadowingModel) ReceptionProbability(distance float64) float64 {
+	margin := m.meanPower(distance) - m.sensitivity
+	if m.sigma <= 0 {
+		if margin >= 0 {
+			return 1
+		}
+		return 0
+	}
+	return 0.5 * math.Erfc(-margin/(m.sigma*math.Sqrt2))
+}
+
+// RicianModel - логарифмические потери и замирания Райса с K-фактором
+// (отношение мощности прямого луча к рассеянным); при K = 0 - замирания Рэлея.
+type RicianModel struct {
+	budget
+	k float64
+}
+
+func (m *RicianModel) ReceptionProbability(distance float64) float64 {
+	// Отношение порога к средней мощности в линейных единицах
+	ratio := math.Pow(10, (m.sensitivity-m.meanPower(distance))/10)
+	return ricianExceedance(m.k, ratio)
+}
+
+// ricianExceedance возвращает вероятность того, что мгновенная мощность при замираниях
+// Райса превысит ratio от средней. Это функция Маркума Q1(sqrt(2K), sqrt(2(K+1)ratio)),
+// вычисленная как пуассоновская смесь хвостов распределения хи-квадрат с четным
+// числом степеней свободы: ряд сходится без функций Бесселя и переполнений.
+func ricianExceedance(k, ratio float64) float64 {
+	y := (k + 1) * ratio
+	if k == 0 {
+		return math.Exp(-y) // Рэлей
+	}
+
+	poisson := math.Exp(-k) // P(N = 0) для N ~ Poisson(K)
+	term := math.Exp(-y)    // y^j/j! * e^-y при j = 0
+	tail := term            // P(хи-квадрат с 2(n+1) степенями > 2y)
+	sum := poisson * tail
+	weight := poisson
+	for n := 1; n < 10000; n++ {
+		poisson *= k / float64(n)
+		term *= y / float64(n)
+		tail += term
+		sum += poisson * tail
+		weight += poisson
+		// Хвосты не больше 1, поэтому оставшийся вклад ограничен 1 - weight
+		if float64(n) > k && 1-weight < 1e-12 {
+			break
+		}
+	}
+	return math.Min(sum, 1)
+}
@@ -1,7 +1,10 @@
 // Файл: config/config.go
 package config
 
-import "fmt"
+import (
+	"drone_trust_sim/models"
+	"fmt"
+)
 
 // --- Структура конфига остается прежней ---
 type SimulatorConfig struct {
@@ -11,26 +14,122 @@ type SimulatorConfig struct {
 	MaliciousRatio       float64
 	AreaWidth            float64
 	AreaHeight           float64
+	AreaAltitudeMin      float64 // Диапазон высот полета; при Min == Max рой летит в одной плоскости
+	AreaAltitudeMax      float64
 	SimulationTime       float64
 	CHReelectionInterval float64
-	PacketGenInterval    float64
+	PacketGenInterval    float64 // Средний интервал между пакетами узла (CBR, Poisson, Convergecast)
 	CHSelectionAlgorithm string
 	TrustModel           string
 	AlphaTrust           float64
 	TrustThreshold       float64
 	InitialTrustValue    float64
 	LambdaDecay          float64
+	BetaForgetting       float64 // Модель Beta: доля свидетельств пары, сохраняемая при каждом новом наблюдении
+	BetaAging            float64 // Модель Beta: доля свидетельств, сохраняемая за секунду без наблюдений; 1 - без старения
 	InitialEnergy        float64
 	EnergyMin            float64
 	EnergyTx             float64
 	EnergyRx             float64
 	EnergyConsensus      float64
+	EnergyHover          float64 // Расход на удержание в воздухе, в секунду; 0 - полет бесплатен
+	EnergyMove           float64 // Расход на перемещение, на единицу пройденного расстояния
 	MinCompPower         float64
 	MaxCompPower         float64
 	CommunicationRadius  float64
 	ConsensusType        string
 	PoWMiningTime        float64
 	PBFTBaseLatency      float64
+	Seed                 int64 // Зерно ГСЧ прогона; 0 - выбрать по текущему времени
+
+	// Подвижность (см. пакет mobility)
+	MobilityModel          string  // RandomJitter, RandomWaypoint, GaussMarkov, RPGM, Static
+	MobilityUpdateInterval float64 // Шаг обновления положения, с
+	MinSpeed               float64 // Единиц поля в секунду
+	MaxSpeed               float64
+	PauseTime              float64 // Пауза в путевой точке, с (RandomWaypoint, опорная точка RPGM)
+	GaussMarkovAlpha       float64 // Память модели Гаусса-Маркова: 0 - случайное блуждание, 1 - прямолинейно
+	RPGMGroupSize          int
+	RPGMGroupRadius        float64  // Максимальное удаление узла от опорной точки группы
+	MobilityTraceFiles     []string // CSV (time,id,x,y[,z]) и GPX траектории для модели Trace
+
+	// Радиоканал (см. пакет channel)
+	ChannelModel      string  // UnitDisk, FreeSpace, LogDistance, Rayleigh, Rician
+	TxPower           float64 // Мощность передатчика, дБм
+	Frequency         float64 // Несущая частота, Гц
+	RxSensitivity     float64 // Чувствительность приемника, дБм; 0 - калибровка по CommunicationRadius
+	PathLossExponent  float64 // Показатель затухания для LogDistance, Rayleigh и Rician
+	ShadowingSigma    float64 // СКО лог-нормального затенения, дБ
+	RicianK           float64 // K-фактор Райса (линейный)
+	ReferenceDistance float64 // Опорное расстояние модели потерь, м
+	MinLinkQuality    float64 // Минимальная вероятность приема, при которой узел считается соседом
+
+	// MAC-уровень
+	MACModel     string  // Ideal - без состязания за канал, CSMA - CSMA/CA с отсрочкой и коллизиями
+	MACSlotTime  float64 // Длительность слота отсрочки, с
+	MACCWMin     int     // Начальное окно состязания, слотов
+	MACCWMax     int     // Максимальное окно после удвоений
+	MACMaxRetry  int     // Повторные попытки передачи кадра до сброса
+	MACFrameTime float64 // Время передачи одного кадра в эфире, с (если не заданы LinkDataRates)
+
+	// Пропускная способность и очереди
+	DataPacketSize    int       // Размер пакета данных, байт
+	ControlPacketSize int       // Размер служебного сообщения (консенсус), байт
+	LinkDataRates     []float64 // Скорости линии, бит/с, по полосам расстояния от ближней к дальней; пусто - прежние фиксированные задержки
+	MACQueueCapacity  int       // Емкость очереди передачи узла, кадров; 0 - без ограничения
+
+	// Маршрутизация (см. пакет routing)
+	RoutingProtocol    string  // ClusterGreedy (исходная жадная по кластерам), AODV, DSR, OLSR, GPSR
+	RoutingTrustFilter bool    // Не прокладывать маршруты через узлы с доверием ниже TrustThreshold
+	HelloInterval      float64 // Период HELLO (OLSR) и маяков положения (GPSR), с
+	TCInterval         float64 // Период рассылки TC (OLSR), с
+	RouteTimeout       float64 // Время жизни неиспользуемого маршрута AODV, с
+	DiscoveryTimeout   float64 // Ожидание ответа на первый RREQ (AODV, DSR), с; далее удваивается
+	DiscoveryRetries   int     // Повторы RREQ, после которых ожидающие пакеты сбрасываются
+	MaxHops            int     // TTL пакета данных: сколько раз его можно передать, прежде чем он будет сброшен
+
+	// Сквозные подтверждения (ACK) пакетов данных
+	EndToEndAck        bool    // Получатель подтверждает каждый пакет данных, источник повторяет неподтвержденные
+	AckTimeout         float64 // Ожидание ACK до повторной передачи, с
+	MaxRetransmissions int     // Повторы пакета, после которых источник перестает ждать ACK
+	AckPacketSize      int     // Размер подтверждения, байт
+	AckEvidenceWeight  float64 // Вес свидетельства Failure_NoAck против узла маршрута относительно прямого наблюдения, (0, 1]
+
+	// Пользовательский трафик (см. пакет traffic) и наземные станции
+	TrafficModel   string         // CBR (по умолчанию), Poisson, OnOff, Convergecast
+	BurstInterval  float64        // Интервал между пакетами внутри пачки (OnOff), с
+	OnTime         float64        // Средняя длительность пачки (OnOff), с
+	OffTime        float64        // Средняя пауза между пачками (OnOff), с
+	GroundStations []models.Point // Положения наземных станций управления; их ID следуют за дронами
+
+	// Динамический состав роя: запланированные события и пуассоновские потоки
+	MembershipEvents []MembershipEvent // Запланированные входы, уходы и отказы узлов
+	JoinRate         float64           // Интенсивность появления новых дронов, 1/с; 0 - без новых узлов
+	LeaveRate        float64           // Интенсивность ухода случайного работающего дрона (например, на подзарядку), 1/с
+	CrashRate        float64           // Интенсивность необратимых отказов случайного работающего дрона, 1/с
+	ReturnDelay      float64           // Среднее время отсутствия ушедшего дрона, с; 0 - ушедшие не возвращаются
+	NewcomerTrust    float64           // Начальное доверие роя к новому дрону; 0 - InitialTrustValue
+
+	// Контрольные точки: периодически и/или в заданные моменты модельного времени
+	CheckpointInterval float64   // 0 - без периодических точек
+	CheckpointTimes    []float64 // Дополнительные моменты сохранения
+	CheckpointDir      string    // Куда писать файлы checkpoint_t<время>.gob
+
+	// Рисунки топологии роя (SVG) в заданные моменты модельного времени
+	SnapshotTimes []float64
+	SnapshotDir   string // Куда писать файлы topology_t<время>.svg
+
+	SampleInterval float64 // Период отсчетов временного ряда метрик, с; 0 - без временного ряда
+}
+
+// MembershipEvent - запланированное изменение состава роя.
+// Action: "join" - вход нового дрона (NodeID = -1) или возвращение ушедшего (его ID),
+// "leave" - уход с возможностью вернуться, "crash" - необратимый отказ.
+// Для leave и crash NodeID = -1 означает случайный работающий узел.
+type MembershipEvent struct {
+	Time   float64
+	Action string
+	NodeID int
 }
 
 // --- Базовый шаблон со значениями по умолчанию ---
@@ -43,6 +142,8 @@ func getBaseTemplate() *SimulatorConfig {
 		TrustThreshold:       0.5,
 		InitialTrustValue:    0.5,
 		LambdaDecay:          0.1,
+		BetaForgetting:       0.98,
+		BetaAging:            1.0,
 		InitialEnergy:        5000.0,
 		EnergyMin:            500.0,
 		EnergyTx:             0.5,
@@ -51,10 +152,61 @@ func getBaseTemplate() *SimulatorConfig {
 		MaxCompPower:         2.0,
 		PoWMiningTime:        5.0,
 		PBFTBaseLatency:      0.5,
+
+		// MaxSpeed = 10 при шаге 1 с соответствует исходному дрожанию ±5 единиц в секунду
+		MobilityModel:          "RandomJitter",
+		MobilityUpdateInterval: 1.0,
+		MinSpeed:               1.0,
+		MaxSpeed:               10.0,
+		PauseTime:              2.0,
+		GaussMarkovAlpha:       0.75,
+		RPGMGroupSize:          5,
+		RPGMGroupRadius:        50.0,
+
+		// UnitDisk сохраняет исходное поведение; остальные параметры - для вероятностных моделей
+		ChannelModel:      "UnitDisk",
+		TxPower:           20.0,
+		Frequency:         2.4e9,
+		PathLossExponent:  2.7,
+		ShadowingSigma:    4.0,
+		RicianK:           3.0,
+		ReferenceDistance: 1.0,
+		MinLinkQuality:    0.5,
+
+		MACModel:     "Ideal",
+		MACSlotTime:  0.001,
+		MACCWMin:     8,
+		MACCWMax:     256,
+		MACMaxRetry:  4,
+		MACFrameTime: 0.01,
+
+		DataPacketSize:    1024,
+		ControlPacketSize: 256,
+		MACQueueCapacity:  50,
+
+		RoutingProtocol:    "ClusterGreedy",
+		RoutingTrustFilter: true,
+		HelloInterval:      2.0,
+		TCInterval:         5.0,
+		RouteTimeout:       10.0,
+		DiscoveryTimeout:   0.5,
+		DiscoveryRetries:   2,
+		MaxHops:            15,
+
+		AckTimeout:         2.0,
+		MaxRetransmissions: 2,
+		AckPacketSize:      64,
+		AckEvidenceWeight:  0.1,
+
+		// Средняя интенсивность OnOff (0.25 с в пачке, активна четверть времени) та же, что у CBR
+		TrafficModel:  "CBR",
+		BurstInterval: 0.25,
+		OnTime:        5.0,
+		OffTime:       15.0,
 	}
 }
 
-// --- Шаблоны для каждого из 6 алгоритмов ---
+// --- Шаблоны для каждого из 7 алгоритмов ---
 
 func getBTMSDTemplate() *SimulatorConfig {
 	cfg := getBaseTemplate()
@@ -115,6 +267,47 @@ func getUnifiedPORSTemplate() *SimulatorConfig {
 	return cfg
 }
 
+func getBetaReputationTemplate() *SimulatorConfig {
+	cfg := getBaseTemplate()
+	cfg.AlgorithmName = "Beta Reputation"
+	cfg.CHSelectionAlgorithm = "BaseBTMSD"
+	cfg.TrustModel = "Beta"
+	cfg.ConsensusType = ""
+	return cfg
+}
+
+// allAlgorithmTemplates - все известные шаблоны, включая не вошедшие в план эксперимента
+func allAlgorithmTemplates() []*SimulatorConfig {
+	return []*SimulatorConfig{
+		getBTMSDTemplate(),
+		getPoRSTemplate(),
+		getPBFTTemplate(),
+		getPoWTemplate(),
+		getReputationConsensusTemplate(),
+		getUnifiedPORSTemplate(),
+		getBetaReputationTemplate(),
+	}
+}
+
+// ApplyAlgorithm переключает алгоритмические параметры cfg (выбор CH, модель доверия,
+// консенсус) на шаблон с указанным AlgorithmName. Параметры сценария не меняются.
+// Нужно для ответвления экспериментов от общей контрольной точки.
+func ApplyAlgorithm(cfg *SimulatorConfig, algorithmName string) error {
+	for _, tpl := range allAlgorithmTemplates() {
+		if tpl.AlgorithmName != algorithmName {
+			continue
+		}
+		cfg.AlgorithmName = tpl.AlgorithmName
+		cfg.CHSelectionAlgorithm = tpl.CHSelectionAlgorithm
+		cfg.TrustModel = tpl.TrustModel
+		cfg.InitialTrustValue = tpl.InitialTrustValue
+		cfg.ConsensusType = tpl.ConsensusType
+		cfg.EnergyConsensus = tpl.EnergyConsensus
+		return nil
+	}
+	return fmt.Errorf("неизвестный алгоритм: %q", algorithmName)
+}
+
 // <<< ГЛАВНАЯ ФУНКЦИЯ-ГЕНЕРАТОР >>>
 // GenerateExperimentConfigs создает список всех конфигураций для полного факторного эксперимента.
 func GenerateExperimentConfigs() []*SimulatorConfig {
@@ -126,6 +319,7 @@ func GenerateExperimentConfigs() []*SimulatorConfig {
 		// getPoWTemplate(),
 		// getReputationConsensusTemplate(),
 		getUnifiedPORSTemplate(),
+		getBetaReputationTemplate(),
 	}
 
 	// --- Определяем диапазоны параметров, которые мы будем варьировать ---
@@ -4,7 +4,7 @@ import (
 	"drone_trust_sim/config"
 	"drone_trust_sim/models"
 	"log"
-	"sync"
+	"math/rand/v2"
 )
 
 type TrustManagerProvider interface {
@@ -19,24 +19,49 @@ type SimulatorState interface {
 	GetConfig() *config.SimulatorConfig
 	GetNextPacketID() int
 	GetTrustManager() TrustManagerProvider
+	GetRand() *rand.Rand // ГСЧ симуляции, чтобы раунды были воспроизводимы
 }
 
 // ConsensusEngine - интерфейс для любого механизма консенсуса
 type ConsensusEngine interface {
 	Run(currentTime float64, members []*models.DroneNode, simState SimulatorState) (float64, *models.Block)
+	// Messages перечисляет сообщения раунда, завершившегося блоком block.
+	// Симулятор с моделью MAC передает их как кадры, нагружая общий канал.
+	Messages(members []*models.DroneNode, block *models.Block) []Message
 }
 
-// RunConsensusRound - функция, запускаемая в горутине для эмуляции раунда консенсуса
-// <<< ИЗМЕНЕНО: принимает интерфейс, а не конкретный симулятор >>>
-func RunConsensusRound(currentTime float64, clusterID int, sim SimulatorState, wg *sync.WaitGroup) {
-	defer wg.Done()
+// Message - одно одноадресное сообщение раунда консенсуса
+type Message struct {
+	From, To int
+}
+
+// broadcast - рассылка от узла from всем остальным участникам
+func broadcast(from int, members []*models.DroneNode) []Message {
+	msgs := make([]Message, 0, len(members))
+	for _, m := range members {
+		if m.ID != from {
+			msgs = append(msgs, Message{From: from, To: m.ID})
+		}
+	}
+	return msgs
+}
 
+// RunConsensusRound эмулирует раунд консенсуса в кластере и возвращает его задержку, блок
+// и сообщения раунда. Вызывается синхронно из цикла событий; завершение раунда
+// симулятор планирует сам по задержке (и, при модели MAC, по доставке сообщений).
+// <<< ИЗМЕНЕНО: принимает интерфейс, а не конкретный симулятор >>>
+func RunConsensusRound(currentTime float64, clusterID int, sim SimulatorState) (float64, *models.Block, []Message) {
 	ch := sim.GetClusterHead(clusterID)
-	members := sim.GetClusterMembers(clusterID)
+	var members []*models.DroneNode
+	for _, m := range sim.GetClusterMembers(clusterID) {
+		if m.Alive() { // Узлы, разрядившиеся после перевыборов, в раунде не участвуют
+			members = append(members, m)
+		}
+	}
 	cfg := sim.GetConfig()
 
-	if ch == nil || len(members) <= 1 {
-		return // Консенсус невозможен
+	if ch == nil || !ch.Alive() || len(members) <= 1 {
+		return 0, nil, nil // Консенсус невозможен
 	}
 
 	// log.Printf("t=%.2f: [Кластер %d] Запуск консенсуса (%s) среди %d узлов. Лидер: Дрон %d",
@@ -52,14 +77,14 @@ func RunConsensusRound(currentTime float64, clusterID int, sim SimulatorState, w
 		engine = &PoRSConsensus{}
 	default:
 		log.Printf("Неизвестный тип консенсуса: %s", cfg.ConsensusType)
-		return
+		return 0, nil, nil
 	}
 
-	_, block := engine.Run(currentTime, members, sim)
+	latency, block := engine.Run(currentTime, members, sim)
 
 	if block == nil {
 		log.Printf("t=%.2f: [Кластер %d] Консенсус не удался.", currentTime, clusterID)
-		return
+		return 0, nil, nil
 	}
 
 	// Обновление состояния после консенсуса
@@ -75,4 +100,6 @@ func RunConsensusRound(currentTime float64, clusterID int, sim SimulatorState, w
 
 	// log.Printf("t=%.2f: [Кластер %d] Консенсус завершен. Задержка: %.3f с. Новый блок #%d создан Дроном %d",
 	// 	currentTime+latency, clusterID, latency, block.ID, block.ProposerID)
+
+	return latency, block, engine.Messages(members, block)
 }
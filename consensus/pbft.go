@@ -44,3 +44,18 @@ func (p *PBFT) Run(currentTime float64, members []*models.DroneNode, simState Si
 
 	return latency, block
 }
+
+// Messages возвращает три фазы PBFT: pre-prepare от лидера, prepare от каждой реплики
+// и commit от каждого участника всем остальным - O(n^2) сообщений на раунд.
+func (p *PBFT) Messages(members []*models.DroneNode, block *models.Block) []Message {
+	msgs := broadcast(block.ProposerID, members) // pre-prepare
+	for _, m := range members {
+		if m.ID != block.ProposerID {
+			msgs = append(msgs, broadcast(m.ID, members)...) // prepare
+		}
+	}
+	for _, m := range members {
+		msgs = append(msgs, broadcast(m.ID, members)...) // commit
+	}
+	return msgs
+}
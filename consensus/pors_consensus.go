@@ -76,3 +76,14 @@ func (p *PoRSConsensus) Run(currentTime float64, members []*models.DroneNode, si
 
 	return latency, block
 }
+
+// Messages - лидер рассылает предложение блока, участники отвечают ему голосами
+func (p *PoRSConsensus) Messages(members []*models.DroneNode, block *models.Block) []Message {
+	msgs := broadcast(block.ProposerID, members)
+	for _, m := range members {
+		if m.ID != block.ProposerID {
+			msgs = append(msgs, Message{From: m.ID, To: block.ProposerID})
+		}
+	}
+	return msgs
+}
@@ -3,7 +3,6 @@ package consensus
 
 import (
 	"drone_trust_sim/models"
-	"math/rand"
 )
 
 // PoW реализует интерфейс ConsensusEngine
@@ -16,6 +15,7 @@ func (p *PoW) Run(currentTime float64, members []*models.DroneNode, simState Sim
 	}
 
 	cfg := simState.GetConfig()
+	rng := simState.GetRand()
 
 	// --- Выбор "победителя" пропорционально вычислительной мощности ---
 	var totalPower float64
@@ -28,7 +28,7 @@ func (p *PoW) Run(currentTime float64, members []*models.DroneNode, simState Sim
 	}
 
 	// "Рулетка": выбираем случайное число от 0 до totalPower
-	pick := rand.Float64() * totalPower
+	pick := rng.Float64() * totalPower
 
 	var winner *models.DroneNode
 	var currentPowerSum float64
@@ -46,7 +46,7 @@ func (p *PoW) Run(currentTime float64, members []*models.DroneNode, simState Sim
 	// --- Конец выбора победителя ---
 
 	// Задержка - это время майнинга.
-	latency := cfg.PoWMiningTime + (rand.Float64()-0.5)*cfg.PoWMiningTime*0.2
+	latency := cfg.PoWMiningTime + (rng.Float64()-0.5)*cfg.PoWMiningTime*0.2
 
 	blockID := simState.GetNextPacketID()
 	block := &models.Block{
@@ -57,3 +57,8 @@ func (p *PoW) Run(currentTime float64, members []*models.DroneNode, simState Sim
 
 	return latency, block
 }
+
+// Messages - победитель майнинга рассылает найденный блок участникам
+func (p *PoW) Messages(members []*models.DroneNode, block *models.Block) []Message {
+	return broadcast(block.ProposerID, members)
+}
@@ -3,21 +3,63 @@ package main
 import (
 	"drone_trust_sim/config"
 	"drone_trust_sim/metrics"
+	"drone_trust_sim/models"
 	"drone_trust_sim/simulator"
+	"drone_trust_sim/trace"
+	"drone_trust_sim/visual"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-// runSingleSimulation (без изменений)
-func runSingleSimulation(cfg *config.SimulatorConfig) *metrics.FinalMetrics {
-	sim := simulator.NewSimulator(cfg)
+// runSingleSimulation выполняет один прогон; при непустом tracePath записывает трассу событий
+func runSingleSimulation(cfg *config.SimulatorConfig, tracePath string) (*metrics.FinalMetrics, error) {
+	sim, err := simulator.NewSimulator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tracePath == "" {
+		return sim.Run(), nil
+	}
+
+	f, err := os.Create(tracePath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать файл трассы: %w", err)
+	}
+	defer f.Close()
+
+	sim.Trace = trace.NewWriter(f)
 	finalMetrics := sim.Run()
-	return finalMetrics
+	if err := sim.Trace.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка записи трассы %s: %w", tracePath, err)
+	}
+	return finalMetrics, nil
+}
+
+// replayTrace восстанавливает состояние роя из трассы на момент at и печатает его в JSON
+func replayTrace(path string, at float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	state, err := trace.Replay(f, at)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(state)
 }
 
 // BatchResult хранит результат выполнения одной серии симуляций
@@ -28,8 +70,124 @@ type BatchResult struct {
 	ReportPaths []string
 }
 
-// runSimulationBatch теперь возвращает результат через канал
-func runSimulationBatch(cfg *config.SimulatorConfig, numRuns int) *BatchResult {
+// resumeSimulation продолжает прогон из контрольной точки. Алгоритм и доля злоумышленников
+// могут быть заменены, чтобы ответвить "что если" эксперимент от общего состояния.
+func resumeSimulation(path, algorithmName string, maliciousRatio float64) error {
+	sim, err := simulator.LoadCheckpoint(path)
+	if err != nil {
+		return err
+	}
+	if algorithmName != "" {
+		if err := config.ApplyAlgorithm(sim.Cfg, algorithmName); err != nil {
+			return err
+		}
+		// Модель доверия выбирается при создании менеджера, поэтому переключается отдельно
+		if err := sim.TrustManager.SetModel(sim.Cfg.TrustModel); err != nil {
+			return err
+		}
+	}
+	if maliciousRatio >= 0 {
+		sim.SetMaliciousRatio(maliciousRatio)
+	}
+	log.Printf("Возобновление с t=%.2f (алгоритм: %s, seed: %d)", sim.CurrentTime, sim.Cfg.AlgorithmName, sim.Seed)
+	sim.Run().Print()
+	return nil
+}
+
+// serveSimulation запускает живую визуализацию одного прогона. Конфигурация выбирается
+// по ResultsDir (например, BARC/drones_50_malicious_0.3_area_400), по умолчанию - первая из плана.
+func serveSimulation(configs []*config.SimulatorConfig, addr, name string, speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("скорость воспроизведения должна быть положительной: %v", speed)
+	}
+	cfg := configs[0]
+	if name != "" {
+		i := slices.IndexFunc(configs, func(c *config.SimulatorConfig) bool { return c.ResultsDir == name })
+		if i < 0 {
+			return fmt.Errorf("в плане эксперимента нет конфигурации %q", name)
+		}
+		cfg = configs[i]
+	}
+	sim, err := simulator.NewSimulator(cfg)
+	if err != nil {
+		return err
+	}
+	log.Printf("Конфигурация %s, seed %d", cfg.ResultsDir, sim.Seed)
+	return visual.NewServer(sim, speed).ListenAndServe(addr)
+}
+
+// parseFloats разбирает список чисел через запятую (моменты времени, скорости линий)
+func parseFloats(list string) ([]float64, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var values []float64
+	for _, part := range strings.Split(list, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверное число %q: %w", part, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parseMembership разбирает запланированные изменения состава роя через запятую в виде
+// время:действие[:узел], например 30:leave:5,60:join:5,90:join. Без узла берется -1:
+// новый дрон для join и случайный работающий для leave и crash.
+func parseMembership(list string) ([]config.MembershipEvent, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var events []config.MembershipEvent
+	for _, part := range strings.Split(list, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("неверное событие %q: ожидается время:действие[:узел]", part)
+		}
+		t, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверное время в %q: %w", part, err)
+		}
+		me := config.MembershipEvent{Time: t, Action: fields[1], NodeID: -1}
+		if len(fields) == 3 {
+			if me.NodeID, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, fmt.Errorf("неверный узел в %q: %w", part, err)
+			}
+		}
+		events = append(events, me)
+	}
+	return events, nil
+}
+
+// parseStations разбирает координаты наземных станций через запятую в виде x:y[:z],
+// например 0:0,400:400:10. Без высоты станция стоит на земле.
+func parseStations(list string) ([]models.Point, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var stations []models.Point
+	for _, part := range strings.Split(list, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("неверная станция %q: ожидается x:y[:z]", part)
+		}
+		var coords [3]float64
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("неверная координата в %q: %w", part, err)
+			}
+			coords[i] = v
+		}
+		stations = append(stations, models.Point{X: coords[0], Y: coords[1], Z: coords[2]})
+	}
+	return stations, nil
+}
+
+// runSimulationBatch теперь возвращает результат через канал.
+// Для первых traceRuns прогонов серии записываются трассы событий.
+func runSimulationBatch(cfg *config.SimulatorConfig, numRuns, traceRuns int) *BatchResult {
 	// Эта функция теперь не выводит логи, а возвращает результат
 	result := &BatchResult{Config: cfg}
 
@@ -41,10 +199,49 @@ func runSimulationBatch(cfg *config.SimulatorConfig, numRuns int) *BatchResult {
 
 	allMetrics := make([]*metrics.FinalMetrics, 0, numRuns)
 	for i := 0; i < numRuns; i++ {
-		metrics := runSingleSimulation(cfg)
+		// Каждый прогон получает свое зерно: при заданном базовом seed серия полностью воспроизводима
+		runCfg := *cfg
+		if cfg.Seed != 0 {
+			runCfg.Seed = cfg.Seed + int64(i)
+		}
+		if cfg.CheckpointInterval > 0 || len(cfg.CheckpointTimes) > 0 {
+			runCfg.CheckpointDir = filepath.Join(resultsPath, fmt.Sprintf("checkpoints_run_%03d", i+1))
+		}
+		if i > 0 {
+			runCfg.SnapshotTimes = nil // Рисунки нужны только для первого прогона серии
+		} else if len(cfg.SnapshotTimes) > 0 {
+			runCfg.SnapshotDir = filepath.Join(resultsPath, "topology_run_001")
+		}
+		tracePath := ""
+		if i < traceRuns {
+			tracePath = filepath.Join(resultsPath, fmt.Sprintf("trace_run_%03d.ndjson", i+1))
+		}
+		metrics, err := runSingleSimulation(&runCfg, tracePath)
+		if err != nil {
+			result.Err = err
+			return result
+		}
 		allMetrics = append(allMetrics, metrics)
 	}
 
+	for i, m := range allMetrics {
+		if err := metrics.SaveNodeMetricsCSV(m.Nodes, filepath.Join(resultsPath, fmt.Sprintf("nodes_run_%03d.csv", i+1))); err != nil {
+			result.Err = err
+		}
+	}
+	if cfg.SampleInterval > 0 {
+		for i, m := range allMetrics {
+			if err := metrics.SaveTimeSeriesCSV(m.Samples, filepath.Join(resultsPath, fmt.Sprintf("timeseries_run_%03d.csv", i+1))); err != nil {
+				result.Err = err
+			}
+		}
+		avgSeriesPath := filepath.Join(resultsPath, "average_timeseries.csv")
+		if err := metrics.SaveAverageTimeSeriesCSV(metrics.AverageTimeSeries(allMetrics), avgSeriesPath); err != nil {
+			result.Err = err
+		}
+		result.ReportPaths = append(result.ReportPaths, avgSeriesPath)
+	}
+
 	avgMetrics := metrics.AverageMetrics(allMetrics)
 	result.AvgMetrics = avgMetrics
 
@@ -67,6 +264,73 @@ func runSimulationBatch(cfg *config.SimulatorConfig, numRuns int) *BatchResult {
 func main() {
 	const numRunsPerConfig = 100 // Количество запусков для усреднения
 
+	seed := flag.Int64("seed", 0, "базовое зерно ГСЧ (0 - случайное для каждого прогона)")
+	traceRuns := flag.Int("trace-runs", 0, "сколько первых прогонов каждой серии записывать в NDJSON-трассу")
+	replayPath := flag.String("replay", "", "восстановить состояние из трассы вместо запуска эксперимента")
+	replayAt := flag.Float64("at", 0, "момент времени для -replay")
+	checkpointEvery := flag.Float64("checkpoint-every", 0, "интервал сохранения контрольных точек, с (0 - выключено)")
+	sampleEvery := flag.Float64("sample-every", 0, "период отсчетов временного ряда метрик, с (0 - без временного ряда)")
+	svgAt := flag.String("svg-at", "", "моменты рисунков топологии роя в SVG через запятую (для первого прогона каждой серии), например 30,60,120")
+	checkpointAt := flag.String("checkpoint-at", "", "моменты сохранения контрольных точек через запятую, например 60,90")
+	mobilityModel := flag.String("mobility", "", "модель подвижности для всех конфигураций (по умолчанию из шаблона)")
+	mobilityTraces := flag.String("mobility-traces", "", "файлы траекторий CSV/GPX через запятую для -mobility Trace")
+	channelModel := flag.String("channel", "", "модель радиоканала для всех конфигураций: UnitDisk, FreeSpace, LogDistance, Rayleigh, Rician")
+	macModel := flag.String("mac", "", "модель MAC-уровня для всех конфигураций: Ideal или CSMA")
+	linkRates := flag.String("link-rates", "", "скорости линий, бит/с, по полосам расстояния через запятую, например 54e6,24e6,6e6")
+	routingProtocol := flag.String("routing", "", "протокол маршрутизации для всех конфигураций: ClusterGreedy, AODV, DSR, OLSR, GPSR")
+	maxHops := flag.Int("max-hops", 0, "TTL пакета данных в хопах (0 - из шаблона)")
+	noRoutingTrust := flag.Bool("no-routing-trust", false, "не исключать из маршрутов узлы с доверием ниже порога")
+	endToEndAck := flag.Bool("ack", false, "сквозные подтверждения пакетов данных с повторами по тайм-ауту")
+	maxRetransmissions := flag.Int("max-retransmissions", -1, "для -ack: число повторов неподтвержденного пакета (-1 - из шаблона)")
+	trafficModel := flag.String("traffic", "", "модель трафика для всех конфигураций: CBR, Poisson, OnOff, Convergecast")
+	groundStations := flag.String("gcs", "", "наземные станции x:y[:z] через запятую, например 0:0,400:400")
+	membership := flag.String("membership", "", "запланированные изменения состава роя время:действие[:узел] через запятую (действия join, leave, crash)")
+	joinRate := flag.Float64("join-rate", 0, "интенсивность входа новых дронов, 1/с")
+	leaveRate := flag.Float64("leave-rate", 0, "интенсивность ухода дронов на подзарядку, 1/с")
+	crashRate := flag.Float64("crash-rate", 0, "интенсивность необратимых отказов дронов, 1/с")
+	returnDelay := flag.Float64("return-delay", 0, "среднее время отсутствия ушедшего дрона, с (0 - не возвращаются)")
+	newcomerTrust := flag.Float64("newcomer-trust", 0, "начальное доверие к новому дрону (0 - InitialTrustValue шаблона)")
+	serveAddr := flag.String("serve", "", "адрес живой визуализации одного прогона в браузере, например :8080")
+	serveConfig := flag.String("serve-config", "", "для -serve: конфигурация плана (ResultsDir), например BARC/drones_50_malicious_0.3_area_400")
+	serveSpeed := flag.Float64("serve-speed", 1, "для -serve: скорость воспроизведения, модельных секунд за секунду")
+	resumePath := flag.String("resume", "", "продолжить прогон из контрольной точки вместо запуска эксперимента")
+	resumeAlgorithm := flag.String("algorithm", "", "для -resume: переключиться на алгоритм с этим именем")
+	resumeMalicious := flag.Float64("malicious", -1, "для -resume: новая доля злонамеренных узлов (-1 - без изменений)")
+	flag.Parse()
+
+	if *replayPath != "" {
+		if err := replayTrace(*replayPath, *replayAt); err != nil {
+			log.Fatalf("Ошибка воспроизведения трассы: %v", err)
+		}
+		return
+	}
+	if *resumePath != "" {
+		if err := resumeSimulation(*resumePath, *resumeAlgorithm, *resumeMalicious); err != nil {
+			log.Fatalf("Ошибка возобновления: %v", err)
+		}
+		return
+	}
+	checkpointTimes, err := parseFloats(*checkpointAt)
+	if err != nil {
+		log.Fatalf("Ошибка в -checkpoint-at: %v", err)
+	}
+	snapshotTimes, err := parseFloats(*svgAt)
+	if err != nil {
+		log.Fatalf("Ошибка в -svg-at: %v", err)
+	}
+	linkDataRates, err := parseFloats(*linkRates)
+	if err != nil {
+		log.Fatalf("Ошибка в -link-rates: %v", err)
+	}
+	membershipEvents, err := parseMembership(*membership)
+	if err != nil {
+		log.Fatalf("Ошибка в -membership: %v", err)
+	}
+	stations, err := parseStations(*groundStations)
+	if err != nil {
+		log.Fatalf("Ошибка в -gcs: %v", err)
+	}
+
 	// --- Параллельное выполнение ---
 	// Ограничиваем количество одновременно работающих "тяжелых" горутин
 	// числом доступных ядер процессора.
@@ -76,6 +340,62 @@ func main() {
 	log.Println("Генерация плана эксперимента (DOE)...")
 	experimentConfigs := config.GenerateExperimentConfigs()
 	log.Printf("План сгенерирован. Всего конфигураций для теста: %d", len(experimentConfigs))
+	for _, cfg := range experimentConfigs {
+		cfg.Seed = *seed
+		cfg.CheckpointInterval = *checkpointEvery
+		cfg.CheckpointTimes = checkpointTimes
+		cfg.SnapshotTimes = snapshotTimes
+		cfg.SampleInterval = *sampleEvery
+		if *mobilityModel != "" {
+			cfg.MobilityModel = *mobilityModel
+		}
+		if *mobilityTraces != "" {
+			cfg.MobilityTraceFiles = strings.Split(*mobilityTraces, ",")
+		}
+		if *channelModel != "" {
+			cfg.ChannelModel = *channelModel
+		}
+		if *macModel != "" {
+			cfg.MACModel = *macModel
+		}
+		if linkDataRates != nil {
+			cfg.LinkDataRates = linkDataRates
+		}
+		if *routingProtocol != "" {
+			cfg.RoutingProtocol = *routingProtocol
+		}
+		if *maxHops > 0 {
+			cfg.MaxHops = *maxHops
+		}
+		if *noRoutingTrust {
+			cfg.RoutingTrustFilter = false
+		}
+		cfg.EndToEndAck = *endToEndAck
+		if *maxRetransmissions >= 0 {
+			cfg.MaxRetransmissions = *maxRetransmissions
+		}
+		if *trafficModel != "" {
+			cfg.TrafficModel = *trafficModel
+		}
+		if stations != nil {
+			cfg.GroundStations = stations
+		}
+		cfg.MembershipEvents = membershipEvents
+		cfg.JoinRate = *joinRate
+		cfg.LeaveRate = *leaveRate
+		cfg.CrashRate = *crashRate
+		cfg.ReturnDelay = *returnDelay
+		cfg.NewcomerTrust = *newcomerTrust
+		if err := simulator.ValidateConfig(cfg); err != nil {
+			log.Fatalf("Неверная конфигурация '%s' (%s): %v", cfg.AlgorithmName, cfg.ResultsDir, err)
+		}
+	}
+	if *serveAddr != "" {
+		if err := serveSimulation(experimentConfigs, *serveAddr, *serveConfig, *serveSpeed); err != nil {
+			log.Fatalf("Ошибка визуализации: %v", err)
+		}
+		return
+	}
 
 	startTime := time.Now()
 
@@ -97,7 +417,7 @@ func main() {
 			defer func() { <-semaphore }()
 
 			// Выполняем серию симуляций и отправляем результат в канал
-			resultsChan <- runSimulationBatch(config, numRunsPerConfig)
+			resultsChan <- runSimulationBatch(config, numRunsPerConfig, *traceRuns)
 		}(cfg)
 	}
 
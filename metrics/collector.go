@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"drone_trust_sim/models"
+	"maps"
+	"slices"
 	"sync"
 )
 
@@ -13,12 +16,52 @@ type Collector struct {
 	TotalEnergyConsumed float64
 	TotalHops           int
 	CHChanges           int
-	LastCHState         map[int]int // clusterID -> chID
+	LastCHState         map[int]int                      // clusterID -> chID
+	Drops               map[models.InteractionResult]int // Потерянные пакеты данных по причинам
+	DeathTimes          []float64                        // Моменты разряда батарей в порядке событий
+	Joins               int                              // Новые дроны, вошедшие в рой
+	Returns             int                              // Возвращения ушедших дронов
+	Leaves              int                              // Уходы с возможностью вернуться
+	Crashes             int                              // Необратимые отказы
+	ControlPackets      int                              // Переданные служебные пакеты маршрутизации
+	DeliveredBytes      int                              // Байты данных, доставленные получателям (без дубликатов)
+	DataBytesSent       int                              // Байты пакетов данных, переданные по всем хопам
+	AckBytesSent        int                              // Байты подтверждений, переданные по всем хопам
+	AcksSent            int                              // Подтверждения, отправленные получателями
+	Retransmissions     int                              // Повторы пакетов данных по тайм-ауту ACK
+	Samples             []Sample                         // Временной ряд метрик (см. RecordSample)
+	window              window                           // Значения счетчиков на момент предыдущего отсчета
+	CHTime              map[int]float64                  // Узел -> суммарное время в роли CH по завершенным срокам
+	CHSince             map[int]float64                  // Действующие CH -> начало текущего срока
+	FirstDistrusted     map[int]float64                  // Узел -> момент, когда доверие к нему хотя бы одного узла впервые опустилось ниже порога
+}
+
+// Sample - отсчет временного ряда. Величины "за окно" считаются с предыдущего отсчета.
+type Sample struct {
+	Time           float64
+	PDR            float64 // Доставлено за окно / отправлено за окно
+	MeanDelay      float64 // Средняя задержка пакетов, доставленных за окно
+	ResidualEnergy float64 // Суммарная остаточная энергия работающих дронов, Дж
+	Clusters       int
+	CHChanges      int // Смены CH за окно
+	AliveNodes     int // Работающие дроны (без наземных станций)
+	FalsePositives int // Классификация по TrustThreshold на момент отсчета, как в FinalMetrics
+	FalseNegatives int
+}
+
+// window - счетчики на момент предыдущего отсчета временного ряда
+type window struct {
+	Sent, Delivered, CHChanges int
+	Delay                      float64
 }
 
 func NewCollector() *Collector {
 	return &Collector{
-		LastCHState: make(map[int]int),
+		LastCHState:     make(map[int]int),
+		Drops:           make(map[models.InteractionResult]int),
+		CHTime:          make(map[int]float64),
+		CHSince:         make(map[int]float64),
+		FirstDistrusted: make(map[int]float64),
 	}
 }
 
@@ -28,11 +71,80 @@ func (mc *Collector) RecordPacketSent() {
 	mc.PacketsSent++
 }
 
-func (mc *Collector) RecordPacketDelivered(delay float64) {
+func (mc *Collector) RecordPacketDelivered(delay float64, size int) {
 	mc.Lock()
 	defer mc.Unlock()
 	mc.PacketsDelivered++
 	mc.TotalDelay += delay
+	mc.DeliveredBytes += size
+}
+
+// RecordTransmission учитывает передачу пакета данных или подтверждения на один хоп
+func (mc *Collector) RecordTransmission(size int, isAck bool) {
+	mc.Lock()
+	defer mc.Unlock()
+	if isAck {
+		mc.AckBytesSent += size
+	} else {
+		mc.DataBytesSent += size
+	}
+}
+
+func (mc *Collector) RecordAckSent() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.AcksSent++
+}
+
+func (mc *Collector) RecordRetransmission() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.Retransmissions++
+}
+
+// RecordDrop учитывает потерю пакета данных по указанной причине
+func (mc *Collector) RecordDrop(reason models.InteractionResult) {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.Drops[reason]++
+}
+
+// RecordNodeDeath фиксирует момент, когда узел разрядил батарею
+func (mc *Collector) RecordNodeDeath(t float64) {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.DeathTimes = append(mc.DeathTimes, t)
+}
+
+func (mc *Collector) RecordJoin() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.Joins++
+}
+
+func (mc *Collector) RecordReturn() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.Returns++
+}
+
+func (mc *Collector) RecordLeave() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.Leaves++
+}
+
+func (mc *Collector) RecordCrash() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.Crashes++
+}
+
+// RecordControlPacket учитывает передачу служебного пакета протокола маршрутизации
+func (mc *Collector) RecordControlPacket() {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.ControlPackets++
 }
 
 func (mc *Collector) RecordEnergyConsumed(energy float64) {
@@ -63,3 +175,160 @@ func (mc *Collector) RecordCHChange(currentCHState map[int]int) {
 	mc.CHChanges += changes
 	mc.LastCHState = currentCHState
 }
+
+// RecordCHRoles отмечает действующих CH (ID кластера -> ID узла) на момент t:
+// открывает сроки новых CH и закрывает сроки узлов, переставших быть CH
+func (mc *Collector) RecordCHRoles(t float64, heads map[int]int) {
+	mc.Lock()
+	defer mc.Unlock()
+	current := make(map[int]bool, len(heads))
+	for _, id := range heads {
+		current[id] = true
+		if _, ok := mc.CHSince[id]; !ok {
+			mc.CHSince[id] = t
+		}
+	}
+	for id, since := range mc.CHSince {
+		if !current[id] {
+			mc.CHTime[id] += t - since
+			delete(mc.CHSince, id)
+		}
+	}
+}
+
+// RecordDistrust фиксирует момент t, если узел признан недоверенным впервые
+func (mc *Collector) RecordDistrust(t float64, nodeID int) {
+	mc.Lock()
+	defer mc.Unlock()
+	if _, ok := mc.FirstDistrusted[nodeID]; !ok {
+		mc.FirstDistrusted[nodeID] = t
+	}
+}
+
+// RecordSample добавляет отсчет временного ряда в момент t. clusters - число кластеров.
+func (mc *Collector) RecordSample(t float64, simResultProvider SimulationResultProvider, clusters int) {
+	mc.Lock()
+	defer mc.Unlock()
+
+	nodes := simResultProvider.GetNodes()
+	smp := Sample{Time: t, Clusters: clusters, CHChanges: mc.CHChanges - mc.window.CHChanges}
+	if sent := mc.PacketsSent - mc.window.Sent; sent > 0 {
+		smp.PDR = float64(mc.PacketsDelivered-mc.window.Delivered) / float64(sent)
+	}
+	if delivered := mc.PacketsDelivered - mc.window.Delivered; delivered > 0 {
+		smp.MeanDelay = (mc.TotalDelay - mc.window.Delay) / float64(delivered)
+	}
+	for _, n := range nodes {
+		if n.Alive() && !n.IsGroundStation {
+			smp.AliveNodes++
+			smp.ResidualEnergy += n.Energy
+		}
+	}
+	smp.FalsePositives, smp.FalseNegatives, _ = classify(nodes, simResultProvider.GetTrustManagerForMetrics(), simResultProvider.GetConfig().TrustThreshold)
+
+	mc.Samples = append(mc.Samples, smp)
+	mc.window = window{Sent: mc.PacketsSent, Delivered: mc.PacketsDelivered, CHChanges: mc.CHChanges, Delay: mc.TotalDelay}
+}
+
+// State - сериализуемая копия накопленных счетчиков (для контрольных точек)
+type State struct {
+	PacketsSent         int
+	PacketsDelivered    int
+	TotalDelay          float64
+	TotalEnergyConsumed float64
+	TotalHops           int
+	CHChanges           int
+	LastCHState         map[int]int
+	Drops               map[models.InteractionResult]int
+	DeathTimes          []float64
+	Joins               int
+	Returns             int
+	Leaves              int
+	Crashes             int
+	ControlPackets      int
+	DeliveredBytes      int
+	DataBytesSent       int
+	AckBytesSent        int
+	AcksSent            int
+	Retransmissions     int
+	Samples             []Sample
+	Window              window
+	CHTime              map[int]float64
+	CHSince             map[int]float64
+	FirstDistrusted     map[int]float64
+}
+
+func (mc *Collector) Snapshot() State {
+	mc.Lock()
+	defer mc.Unlock()
+	return State{
+		PacketsSent:         mc.PacketsSent,
+		PacketsDelivered:    mc.PacketsDelivered,
+		TotalDelay:          mc.TotalDelay,
+		TotalEnergyConsumed: mc.TotalEnergyConsumed,
+		TotalHops:           mc.TotalHops,
+		CHChanges:           mc.CHChanges,
+		LastCHState:         maps.Clone(mc.LastCHState),
+		Drops:               maps.Clone(mc.Drops),
+		DeathTimes:          slices.Clone(mc.DeathTimes),
+		Joins:               mc.Joins,
+		Returns:             mc.Returns,
+		Leaves:              mc.Leaves,
+		Crashes:             mc.Crashes,
+		ControlPackets:      mc.ControlPackets,
+		DeliveredBytes:      mc.DeliveredBytes,
+		DataBytesSent:       mc.DataBytesSent,
+		AckBytesSent:        mc.AckBytesSent,
+		AcksSent:            mc.AcksSent,
+		Retransmissions:     mc.Retransmissions,
+		Samples:             slices.Clone(mc.Samples),
+		Window:              mc.window,
+		CHTime:              maps.Clone(mc.CHTime),
+		CHSince:             maps.Clone(mc.CHSince),
+		FirstDistrusted:     maps.Clone(mc.FirstDistrusted),
+	}
+}
+
+func (mc *Collector) Restore(st State) {
+	mc.Lock()
+	defer mc.Unlock()
+	mc.PacketsSent = st.PacketsSent
+	mc.PacketsDelivered = st.PacketsDelivered
+	mc.TotalDelay = st.TotalDelay
+	mc.TotalEnergyConsumed = st.TotalEnergyConsumed
+	mc.TotalHops = st.TotalHops
+	mc.CHChanges = st.CHChanges
+	mc.LastCHState = maps.Clone(st.LastCHState)
+	if mc.LastCHState == nil {
+		mc.LastCHState = make(map[int]int)
+	}
+	mc.DeathTimes = slices.Clone(st.DeathTimes)
+	mc.Joins = st.Joins
+	mc.Returns = st.Returns
+	mc.Leaves = st.Leaves
+	mc.Crashes = st.Crashes
+	mc.ControlPackets = st.ControlPackets
+	mc.DeliveredBytes = st.DeliveredBytes
+	mc.DataBytesSent = st.DataBytesSent
+	mc.AckBytesSent = st.AckBytesSent
+	mc.AcksSent = st.AcksSent
+	mc.Retransmissions = st.Retransmissions
+	mc.Samples = slices.Clone(st.Samples)
+	mc.window = st.Window
+	mc.Drops = maps.Clone(st.Drops)
+	if mc.Drops == nil {
+		mc.Drops = make(map[models.InteractionResult]int)
+	}
+	mc.CHTime = maps.Clone(st.CHTime)
+	if mc.CHTime == nil {
+		mc.CHTime = make(map[int]float64)
+	}
+	mc.CHSince = maps.Clone(st.CHSince)
+	if mc.CHSince == nil {
+		mc.CHSince = make(map[int]float64)
+	}
+	mc.FirstDistrusted = maps.Clone(st.FirstDistrusted)
+	if mc.FirstDistrusted == nil {
+		mc.FirstDistrusted = make(map[int]float64)
+	}
+}
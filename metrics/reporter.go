@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 )
 
 // TrustManagerReader описывает, что нужно от менеджера доверия
 type TrustManagerReader interface {
 	GetTrust(observerID, targetID int) float64
+	CalculateMeanIncomingTrust(candidateID int) float64
 }
 
 // SimulationResultProvider описывает, что нужно от симулятора для финального отчета
@@ -20,6 +22,7 @@ type SimulationResultProvider interface {
 	GetTrustManagerForMetrics() TrustManagerReader
 	GetConfig() *config.SimulatorConfig
 	GetSimulationTime() float64
+	GetSeed() int64
 }
 
 type FinalMetrics struct {
@@ -31,6 +34,55 @@ type FinalMetrics struct {
 	FalsePositives   int
 	FalseNegatives   int
 	TrueNegative     int
+	CollisionDrops   int // Пакеты данных, потерянные из-за коллизий после всех повторов MAC
+	QueueDrops       int // Пакеты данных, отброшенные из-за переполнения очереди передачи
+	// Остальные причины потерь пакетов данных
+	MaliciousDrops  int // Сброшены злонамеренными узлами
+	OutOfRangeDrops int // Потеряны в канале: получатель вне радиуса или прием не удался
+	NoRouteDrops    int // Протокол маршрутизации не нашел следующий хоп
+	LoopDrops       int // Петля маршрута или исчерпан TTL (MaxHops)
+	NodeDeathDrops  int // Отправитель или получатель разряжен, отказал или покинул рой
+	// Время жизни сети: моменты гибели первого узла, половины узлов и последнего узла.
+	// Если событие не наступило до конца симуляции, значение равно SimulationTime (цензурирование).
+	FirstNodeDeath float64
+	HalfNodesDead  float64
+	LastNodeDeath  float64
+	// Изменения состава роя за прогон
+	NodesJoined   int
+	NodesReturned int
+	NodesLeft     int
+	NodesCrashed  int
+	// Служебные пакеты протокола маршрутизации (каждая широковещательная передача - один пакет)
+	ControlPackets int
+	// Сквозные подтверждения: полезная пропускная способность (уникальные доставленные данные),
+	// повторы по тайм-ауту, отправленные ACK и их доля в байтах трафика данных по всем хопам
+	Goodput         float64 // бит/с
+	Retransmissions int
+	AckPackets      int
+	AckOverhead     float64
+	Seed            int64 // Зерно ГСЧ, с которым был получен прогон
+	// Временной ряд метрик (при SampleInterval > 0); в CSV итогов не входит, см. SaveTimeSeriesCSV
+	Samples []Sample
+	// Итоги по узлам в порядке ID; в CSV итогов не входят, см. SaveNodeMetricsCSV
+	Nodes []NodeMetrics
+}
+
+// NodeMetrics - итоги прогона для одного узла
+type NodeMetrics struct {
+	ID                  int
+	IsMalicious         bool // Истинная роль узла
+	IsGroundStation     bool
+	Status              models.NodeStatus
+	PacketsSent         int
+	PacketsDelivered    int
+	PacketsForwarded    int
+	PacketsDroppedByMe  int
+	ConsensusRounds     int
+	ValidBlocksProposed int
+	Energy              float64 // Остаточная энергия на конец прогона, Дж
+	CHTime              float64 // Суммарное время в роли CH, с
+	MeanIncomingTrust   float64
+	FirstDistrusted     float64 // Момент, когда узел впервые признан недоверенным хотя бы одним работающим узлом (как в classify); -1 - не признавался
 }
 
 func (mc *Collector) CalculateFinalMetrics(simResultProvider SimulationResultProvider) *FinalMetrics {
@@ -42,7 +94,7 @@ func (mc *Collector) CalculateFinalMetrics(simResultProvider SimulationResultPro
 	cfg := simResultProvider.GetConfig()
 	simulationTime := simResultProvider.GetSimulationTime()
 
-	fm := &FinalMetrics{AlgorithmName: cfg.AlgorithmName}
+	fm := &FinalMetrics{AlgorithmName: cfg.AlgorithmName, Seed: simResultProvider.GetSeed(), Samples: slices.Clone(mc.Samples)}
 
 	if mc.PacketsSent > 0 {
 		fm.PDR = float64(mc.PacketsDelivered) / float64(mc.PacketsSent)
@@ -50,8 +102,34 @@ func (mc *Collector) CalculateFinalMetrics(simResultProvider SimulationResultPro
 	if mc.PacketsDelivered > 0 {
 		fm.MeanDelay = mc.TotalDelay / float64(mc.PacketsDelivered)
 	}
+	fm.CollisionDrops = mc.Drops[models.Failure_Collision]
+	fm.QueueDrops = mc.Drops[models.Failure_QueueOverflow]
+	fm.MaliciousDrops = mc.Drops[models.Failure_MaliciousDrop]
+	fm.OutOfRangeDrops = mc.Drops[models.Failure_OutOfRange]
+	fm.NoRouteDrops = mc.Drops[models.Failure_NoRoute]
+	fm.LoopDrops = mc.Drops[models.Failure_PacketLoop]
+	fm.NodeDeathDrops = mc.Drops[models.Failure_NodeDead]
+	// Наземные станции питаются от сети и в расчете времени жизни не участвуют
+	drones := len(nodes) - len(cfg.GroundStations)
+	fm.FirstNodeDeath = mc.deathTime(1, cfg.SimulationTime)
+	fm.HalfNodesDead = mc.deathTime((drones+1)/2, cfg.SimulationTime)
+	fm.LastNodeDeath = mc.deathTime(drones, cfg.SimulationTime)
+	fm.NodesJoined = mc.Joins
+	fm.NodesReturned = mc.Returns
+	fm.NodesLeft = mc.Leaves
+	fm.NodesCrashed = mc.Crashes
+	fm.ControlPackets = mc.ControlPackets
+	fm.Retransmissions = mc.Retransmissions
+	fm.AckPackets = mc.AcksSent
+	if simulationTime > 0 {
+		fm.Goodput = float64(mc.DeliveredBytes) * 8 / simulationTime
+	}
+	if sent := mc.DataBytesSent + mc.AckBytesSent; sent > 0 {
+		fm.AckOverhead = float64(mc.AckBytesSent) / float64(sent)
+	}
 
-	var totalEnergyConsumed float64
+	// Расход ушедших на подзарядку дронов до их возвращения накоплен в сборщике
+	totalEnergyConsumed := mc.TotalEnergyConsumed
 	for _, n := range nodes {
 		totalEnergyConsumed += (cfg.InitialEnergy - n.Energy)
 	}
@@ -65,17 +143,52 @@ func (mc *Collector) CalculateFinalMetrics(simResultProvider SimulationResultPro
 		fm.CHChurnRate = float64(mc.CHChanges) / simulationMinutes
 	}
 
-	fp := 0
-	fn := 0
-	tn := 0
+	// Классификация оценивается по составу роя на конец прогона
+	fm.FalsePositives, fm.FalseNegatives, fm.TrueNegative = classify(nodes, tm, cfg.TrustThreshold)
+
+	for _, n := range nodes {
+		nm := NodeMetrics{
+			ID:                  n.ID,
+			IsMalicious:         n.IsMalicious,
+			IsGroundStation:     n.IsGroundStation,
+			Status:              n.Status,
+			PacketsSent:         n.PacketsSent,
+			PacketsDelivered:    n.PacketsDelivered,
+			PacketsForwarded:    n.PacketsForwarded,
+			PacketsDroppedByMe:  n.PacketsDroppedByMe,
+			ConsensusRounds:     n.ConsensusRounds,
+			ValidBlocksProposed: n.ValidBlocksProposed,
+			Energy:              n.Energy,
+			CHTime:              mc.CHTime[n.ID],
+			MeanIncomingTrust:   tm.CalculateMeanIncomingTrust(n.ID),
+			FirstDistrusted:     -1,
+		}
+		if since, ok := mc.CHSince[n.ID]; ok {
+			nm.CHTime += simulationTime - since // Срок действующего CH длится до конца прогона
+		}
+		if t, ok := mc.FirstDistrusted[n.ID]; ok {
+			nm.FirstDistrusted = t
+		}
+		fm.Nodes = append(fm.Nodes, nm)
+	}
+
+	return fm
+}
+
+// classify сравнивает доверие каждого работающего узла к каждому другому с порогом
+// и возвращает число ошибок и верных недоверий по всем парам наблюдатель-цель
+func classify(nodes []*models.DroneNode, tm TrustManagerReader, threshold float64) (fp, fn, tn int) {
 	for i := range nodes {
+		if !nodes[i].Alive() {
+			continue
+		}
 		for j := range nodes {
-			if i == j {
+			if i == j || !nodes[j].Alive() {
 				continue
 			}
 
 			trustValue := tm.GetTrust(i, j)
-			isConsideredTrusted := trustValue >= cfg.TrustThreshold
+			isConsideredTrusted := trustValue >= threshold
 			isActuallyMalicious := nodes[j].IsMalicious
 
 			if !isActuallyMalicious && !isConsideredTrusted {
@@ -89,11 +202,15 @@ func (mc *Collector) CalculateFinalMetrics(simResultProvider SimulationResultPro
 			}
 		}
 	}
-	fm.FalsePositives = fp
-	fm.FalseNegatives = fn
-	fm.TrueNegative = tn
+	return fp, fn, tn
+}
 
-	return fm
+// deathTime возвращает момент k-й гибели узла или censorAt, если столько узлов не погибло
+func (mc *Collector) deathTime(k int, censorAt float64) float64 {
+	if k <= 0 || k > len(mc.DeathTimes) {
+		return censorAt
+	}
+	return mc.DeathTimes[k-1]
 }
 
 func (fm *FinalMetrics) Print() {
@@ -106,6 +223,15 @@ func (fm *FinalMetrics) Print() {
 	fmt.Printf("Ошибки классификации (False Positives): %d\n", fm.FalsePositives)
 	fmt.Printf("Ошибки классификации (False Negatives): %d\n", fm.FalseNegatives)
 	fmt.Printf("True Neagatives: %d\n", fm.TrueNegative)
+	fmt.Printf("Потери из-за коллизий: %d\n", fm.CollisionDrops)
+	fmt.Printf("Потери из-за переполнения очередей: %d\n", fm.QueueDrops)
+	fmt.Printf("Прочие потери (злонамеренные / канал / нет маршрута / петля или TTL / гибель узла): %d / %d / %d / %d / %d\n",
+		fm.MaliciousDrops, fm.OutOfRangeDrops, fm.NoRouteDrops, fm.LoopDrops, fm.NodeDeathDrops)
+	fmt.Printf("Время жизни сети (первый / половина / последний узел): %.1f / %.1f / %.1f с\n", fm.FirstNodeDeath, fm.HalfNodesDead, fm.LastNodeDeath)
+	fmt.Printf("Состав роя (вошли / вернулись / ушли / отказали): %d / %d / %d / %d\n", fm.NodesJoined, fm.NodesReturned, fm.NodesLeft, fm.NodesCrashed)
+	fmt.Printf("Служебные пакеты маршрутизации: %d\n", fm.ControlPackets)
+	fmt.Printf("Полезная пропускная способность: %.1f бит/с\n", fm.Goodput)
+	fmt.Printf("Повторные передачи: %d, подтверждения: %d (доля в трафике данных %.3f)\n", fm.Retransmissions, fm.AckPackets, fm.AckOverhead)
 	fmt.Println("---------------------------------")
 }
 
@@ -119,7 +245,7 @@ func (fm *FinalMetrics) SaveToCSV(filePath string) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	header := []string{"Algorithm", "PDR", "MeanDelay", "EnergyEfficiency", "CHChurnRate", "FalsePositives", "FalseNegatives", "TrueNegatives"}
+	header := []string{"Algorithm", "PDR", "MeanDelay", "EnergyEfficiency", "CHChurnRate", "FalsePositives", "FalseNegatives", "TrueNegatives", "CollisionDrops", "QueueDrops", "MaliciousDrops", "OutOfRangeDrops", "NoRouteDrops", "LoopDrops", "NodeDeathDrops", "FirstNodeDeath", "HalfNodesDead", "LastNodeDeath", "NodesJoined", "NodesReturned", "NodesLeft", "NodesCrashed", "ControlPackets", "Goodput", "Retransmissions", "AckPackets", "AckOverhead"}
 	data := []string{
 		fm.AlgorithmName,
 		fmt.Sprintf("%.5f", fm.PDR),
@@ -129,6 +255,25 @@ func (fm *FinalMetrics) SaveToCSV(filePath string) error {
 		fmt.Sprintf("%d", fm.FalsePositives),
 		fmt.Sprintf("%d", fm.FalseNegatives),
 		fmt.Sprintf("%d", fm.TrueNegative),
+		fmt.Sprintf("%d", fm.CollisionDrops),
+		fmt.Sprintf("%d", fm.QueueDrops),
+		fmt.Sprintf("%d", fm.MaliciousDrops),
+		fmt.Sprintf("%d", fm.OutOfRangeDrops),
+		fmt.Sprintf("%d", fm.NoRouteDrops),
+		fmt.Sprintf("%d", fm.LoopDrops),
+		fmt.Sprintf("%d", fm.NodeDeathDrops),
+		fmt.Sprintf("%.3f", fm.FirstNodeDeath),
+		fmt.Sprintf("%.3f", fm.HalfNodesDead),
+		fmt.Sprintf("%.3f", fm.LastNodeDeath),
+		fmt.Sprintf("%d", fm.NodesJoined),
+		fmt.Sprintf("%d", fm.NodesReturned),
+		fmt.Sprintf("%d", fm.NodesLeft),
+		fmt.Sprintf("%d", fm.NodesCrashed),
+		fmt.Sprintf("%d", fm.ControlPackets),
+		fmt.Sprintf("%.1f", fm.Goodput),
+		fmt.Sprintf("%d", fm.Retransmissions),
+		fmt.Sprintf("%d", fm.AckPackets),
+		fmt.Sprintf("%.4f", fm.AckOverhead),
 	}
 
 	if err := writer.Write(header); err != nil {
@@ -158,7 +303,7 @@ func SaveAllMetricsToCSV(allMetrics []*FinalMetrics, filePath string) error {
 	defer writer.Flush()
 
 	// Записываем заголовок
-	header := []string{"Run", "Algorithm", "PDR", "MeanDelay", "EnergyEfficiency", "CHChurnRate", "FalsePositives", "FalseNegatives", "TrueNegatives"}
+	header := []string{"Run", "Seed", "Algorithm", "PDR", "MeanDelay", "EnergyEfficiency", "CHChurnRate", "FalsePositives", "FalseNegatives", "TrueNegatives", "CollisionDrops", "QueueDrops", "MaliciousDrops", "OutOfRangeDrops", "NoRouteDrops", "LoopDrops", "NodeDeathDrops", "FirstNodeDeath", "HalfNodesDead", "LastNodeDeath", "NodesJoined", "NodesReturned", "NodesLeft", "NodesCrashed", "ControlPackets", "Goodput", "Retransmissions", "AckPackets", "AckOverhead"}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -167,6 +312,7 @@ func SaveAllMetricsToCSV(allMetrics []*FinalMetrics, filePath string) error {
 	for i, fm := range allMetrics {
 		record := []string{
 			fmt.Sprintf("%d", i+1), // Номер запуска (Run)
+			fmt.Sprintf("%d", fm.Seed),
 			fm.AlgorithmName,
 			fmt.Sprintf("%.5f", fm.PDR),
 			fmt.Sprintf("%.5f", fm.MeanDelay),
@@ -175,6 +321,25 @@ func SaveAllMetricsToCSV(allMetrics []*FinalMetrics, filePath string) error {
 			fmt.Sprintf("%d", fm.FalsePositives),
 			fmt.Sprintf("%d", fm.FalseNegatives),
 			fmt.Sprintf("%d", fm.TrueNegative),
+			fmt.Sprintf("%d", fm.CollisionDrops),
+			fmt.Sprintf("%d", fm.QueueDrops),
+			fmt.Sprintf("%d", fm.MaliciousDrops),
+			fmt.Sprintf("%d", fm.OutOfRangeDrops),
+			fmt.Sprintf("%d", fm.NoRouteDrops),
+			fmt.Sprintf("%d", fm.LoopDrops),
+			fmt.Sprintf("%d", fm.NodeDeathDrops),
+			fmt.Sprintf("%.3f", fm.FirstNodeDeath),
+			fmt.Sprintf("%.3f", fm.HalfNodesDead),
+			fmt.Sprintf("%.3f", fm.LastNodeDeath),
+			fmt.Sprintf("%d", fm.NodesJoined),
+			fmt.Sprintf("%d", fm.NodesReturned),
+			fmt.Sprintf("%d", fm.NodesLeft),
+			fmt.Sprintf("%d", fm.NodesCrashed),
+			fmt.Sprintf("%d", fm.ControlPackets),
+			fmt.Sprintf("%.1f", fm.Goodput),
+			fmt.Sprintf("%d", fm.Retransmissions),
+			fmt.Sprintf("%d", fm.AckPackets),
+			fmt.Sprintf("%.4f", fm.AckOverhead),
 		}
 		if err := writer.Write(record); err != nil {
 			// Можно просто залогировать и продолжить, чтобы не терять весь файл из-за одной строки
@@ -196,7 +361,12 @@ func AverageMetrics(allMetrics []*FinalMetrics) *FinalMetrics {
 
 	numMetrics := float64(len(allMetrics))
 	var sumPDR, sumDelay, sumEnergy, sumChurn float64
-	var sumFP, sumFN, sumTN int
+	var sumFirstDeath, sumHalfDead, sumLastDeath float64
+	var sumFP, sumFN, sumTN, sumCollisions, sumQueueDrops int
+	var sumMalicious, sumOutOfRange, sumNoRoute, sumLoops, sumNodeDeath int
+	var sumJoined, sumReturned, sumLeft, sumCrashed, sumControl int
+	var sumGoodput, sumAckOverhead float64
+	var sumRetransmissions, sumAcks int
 
 	for _, m := range allMetrics {
 		sumPDR += m.PDR
@@ -206,6 +376,25 @@ func AverageMetrics(allMetrics []*FinalMetrics) *FinalMetrics {
 		sumFP += m.FalsePositives
 		sumFN += m.FalseNegatives
 		sumTN += m.TrueNegative
+		sumCollisions += m.CollisionDrops
+		sumQueueDrops += m.QueueDrops
+		sumMalicious += m.MaliciousDrops
+		sumOutOfRange += m.OutOfRangeDrops
+		sumNoRoute += m.NoRouteDrops
+		sumLoops += m.LoopDrops
+		sumNodeDeath += m.NodeDeathDrops
+		sumFirstDeath += m.FirstNodeDeath
+		sumHalfDead += m.HalfNodesDead
+		sumLastDeath += m.LastNodeDeath
+		sumJoined += m.NodesJoined
+		sumReturned += m.NodesReturned
+		sumLeft += m.NodesLeft
+		sumCrashed += m.NodesCrashed
+		sumControl += m.ControlPackets
+		sumGoodput += m.Goodput
+		sumRetransmissions += m.Retransmissions
+		sumAcks += m.AckPackets
+		sumAckOverhead += m.AckOverhead
 	}
 
 	avg.PDR = sumPDR / numMetrics
@@ -216,6 +405,177 @@ func AverageMetrics(allMetrics []*FinalMetrics) *FinalMetrics {
 	avg.FalsePositives = int(float64(sumFP) / numMetrics)
 	avg.FalseNegatives = int(float64(sumFN) / numMetrics)
 	avg.TrueNegative = int(float64(sumTN) / numMetrics)
+	avg.CollisionDrops = int(float64(sumCollisions) / numMetrics)
+	avg.QueueDrops = int(float64(sumQueueDrops) / numMetrics)
+	avg.MaliciousDrops = int(float64(sumMalicious) / numMetrics)
+	avg.OutOfRangeDrops = int(float64(sumOutOfRange) / numMetrics)
+	avg.NoRouteDrops = int(float64(sumNoRoute) / numMetrics)
+	avg.LoopDrops = int(float64(sumLoops) / numMetrics)
+	avg.NodeDeathDrops = int(float64(sumNodeDeath) / numMetrics)
+	avg.FirstNodeDeath = sumFirstDeath / numMetrics
+	avg.HalfNodesDead = sumHalfDead / numMetrics
+	avg.LastNodeDeath = sumLastDeath / numMetrics
+	avg.NodesJoined = int(float64(sumJoined) / numMetrics)
+	avg.NodesReturned = int(float64(sumReturned) / numMetrics)
+	avg.NodesLeft = int(float64(sumLeft) / numMetrics)
+	avg.NodesCrashed = int(float64(sumCrashed) / numMetrics)
+	avg.ControlPackets = int(float64(sumControl) / numMetrics)
+	avg.Goodput = sumGoodput / numMetrics
+	avg.Retransmissions = int(float64(sumRetransmissions) / numMetrics)
+	avg.AckPackets = int(float64(sumAcks) / numMetrics)
+	avg.AckOverhead = sumAckOverhead / numMetrics
 
 	return avg
 }
+
+// AvgSample - отсчет временного ряда, усредненный по прогонам серии. Счетчики
+// дробные: кривые сходимости должны отражать, в какой доле прогонов атакующий еще не изолирован.
+type AvgSample struct {
+	Time           float64
+	PDR            float64
+	MeanDelay      float64
+	ResidualEnergy float64
+	Clusters       float64
+	CHChanges      float64
+	AliveNodes     float64
+	FalsePositives float64
+	FalseNegatives float64
+}
+
+var timeSeriesHeader = []string{"Time", "PDR", "MeanDelay", "ResidualEnergy", "Clusters", "CHChanges", "AliveNodes", "FalsePositives", "FalseNegatives"}
+
+// SaveTimeSeriesCSV сохраняет временной ряд метрик прогона
+func SaveTimeSeriesCSV(samples []Sample, filePath string) error {
+	records := make([][]string, 0, len(samples))
+	for _, s := range samples {
+		records = append(records, []string{
+			fmt.Sprintf("%.2f", s.Time),
+			fmt.Sprintf("%.5f", s.PDR),
+			fmt.Sprintf("%.5f", s.MeanDelay),
+			fmt.Sprintf("%.3f", s.ResidualEnergy),
+			fmt.Sprintf("%d", s.Clusters),
+			fmt.Sprintf("%d", s.CHChanges),
+			fmt.Sprintf("%d", s.AliveNodes),
+			fmt.Sprintf("%d", s.FalsePositives),
+			fmt.Sprintf("%d", s.FalseNegatives),
+		})
+	}
+	return saveTimeSeries(records, filePath)
+}
+
+// SaveAverageTimeSeriesCSV сохраняет усредненный временной ряд серии
+func SaveAverageTimeSeriesCSV(samples []AvgSample, filePath string) error {
+	records := make([][]string, 0, len(samples))
+	for _, s := range samples {
+		records = append(records, []string{
+			fmt.Sprintf("%.2f", s.Time),
+			fmt.Sprintf("%.5f", s.PDR),
+			fmt.Sprintf("%.5f", s.MeanDelay),
+			fmt.Sprintf("%.3f", s.ResidualEnergy),
+			fmt.Sprintf("%.3f", s.Clusters),
+			fmt.Sprintf("%.3f", s.CHChanges),
+			fmt.Sprintf("%.3f", s.AliveNodes),
+			fmt.Sprintf("%.3f", s.FalsePositives),
+			fmt.Sprintf("%.3f", s.FalseNegatives),
+		})
+	}
+	return saveTimeSeries(records, filePath)
+}
+
+func saveTimeSeries(records [][]string, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write(timeSeriesHeader); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AverageTimeSeries усредняет временные ряды серии прогонов по номеру отсчета.
+// Прогоны одной серии имеют одинаковые SampleInterval и SimulationTime, поэтому
+// k-е отсчеты всех прогонов относятся к одному моменту.
+func AverageTimeSeries(allMetrics []*FinalMetrics) []AvgSample {
+	var sums []AvgSample
+	var counts []int
+	for _, m := range allMetrics {
+		for k, s := range m.Samples {
+			if k == len(sums) {
+				sums = append(sums, AvgSample{Time: s.Time})
+				counts = append(counts, 0)
+			}
+			sums[k].PDR += s.PDR
+			sums[k].MeanDelay += s.MeanDelay
+			sums[k].ResidualEnergy += s.ResidualEnergy
+			sums[k].Clusters += float64(s.Clusters)
+			sums[k].CHChanges += float64(s.CHChanges)
+			sums[k].AliveNodes += float64(s.AliveNodes)
+			sums[k].FalsePositives += float64(s.FalsePositives)
+			sums[k].FalseNegatives += float64(s.FalseNegatives)
+			counts[k]++
+		}
+	}
+
+	for k := range sums {
+		n := float64(counts[k])
+		sums[k].PDR /= n
+		sums[k].MeanDelay /= n
+		sums[k].ResidualEnergy /= n
+		sums[k].Clusters /= n
+		sums[k].CHChanges /= n
+		sums[k].AliveNodes /= n
+		sums[k].FalsePositives /= n
+		sums[k].FalseNegatives /= n
+	}
+	return sums
+}
+
+// SaveNodeMetricsCSV сохраняет итоги прогона по узлам
+func SaveNodeMetricsCSV(nodes []NodeMetrics, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"NodeID", "IsMalicious", "IsGroundStation", "Status", "PacketsSent", "PacketsDelivered", "PacketsForwarded", "PacketsDroppedByMe", "ConsensusRounds", "ValidBlocksProposed", "Energy", "CHTime", "MeanIncomingTrust", "FirstDistrusted"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		record := []string{
+			fmt.Sprintf("%d", n.ID),
+			fmt.Sprintf("%t", n.IsMalicious),
+			fmt.Sprintf("%t", n.IsGroundStation),
+			n.Status.String(),
+			fmt.Sprintf("%d", n.PacketsSent),
+			fmt.Sprintf("%d", n.PacketsDelivered),
+			fmt.Sprintf("%d", n.PacketsForwarded),
+			fmt.Sprintf("%d", n.PacketsDroppedByMe),
+			fmt.Sprintf("%d", n.ConsensusRounds),
+			fmt.Sprintf("%d", n.ValidBlocksProposed),
+			fmt.Sprintf("%.3f", n.Energy),
+			fmt.Sprintf("%.3f", n.CHTime),
+			fmt.Sprintf("%.4f", n.MeanIncomingTrust),
+			fmt.Sprintf("%.3f", n.FirstDistrusted),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Файл: mobility/mobility.go
+package mobility
+
+import (
+	"drone_trust_sim/config"
+	"drone_trust_sim/models"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+)
+
+// Model - модель подвижности узлов.
+// Init вызывается для каждого узла до первого перемещения, в том числе при входе
+// или возвращении в рой посреди прогона (now - момент входа);
+// Move переносит узел в положение на момент модельного времени now.
+type Model interface {
+	Init(node *models.DroneNode, now float64)
+	Move(node *models.DroneNode, now float64)
+	Snapshot() State
+	Restore(st State)
+}
+
+// NodeState - состояние движения одного узла (или опорной точки группы RPGM).
+// Каждая модель использует только нужные ей поля.
+type NodeState struct {
+	Position   models.Point // Положение опорной точки группы (RPGM)
+	Target     models.Point // Текущая путевая точка
+	Speed      float64
+	Direction  float64      // Азимут движения, рад (Gauss-Markov)
+	Climb      float64      // Вертикальная скорость, м/с (Gauss-Markov)
+	PauseLeft  float64      // Оставшееся время паузы в путевой точке
+	Offset     models.Point // Смещение узла относительно опорной точки группы (RPGM)
+	LastUpdate float64
+}
+
+// State - сериализуемое состояние модели подвижности (для контрольных точек)
+type State struct {
+	Nodes  map[int]NodeState
+	Groups map[int]NodeState
+}
+
+// Названия моделей в SimulatorConfig.MobilityModel
+const (
+	RandomJitter   = "RandomJitter"
+	RandomWaypoint = "RandomWaypoint"
+	GaussMarkov    = "GaussMarkov"
+	RPGM           = "RPGM"
+	Static         = "Static"
+	Trace          = "Trace" // Записанные траектории из MobilityTraceFiles
+)
+
+// Known сообщает, существует ли модель с таким названием
+func Known(name string) bool {
+	switch name {
+	case RandomJitter, RandomWaypoint, GaussMarkov, RPGM, Static, Trace:
+		return true
+	}
+	return false
+}
+
+// New создает модель подвижности, выбранную в конфигурации
+func New(cfg *config.SimulatorConfig, rng *rand.Rand) (Model, error) {
+	b := newBase(cfg, rng)
+	switch cfg.MobilityModel {
+	case RandomJitter:
+		return &Jitter{base: b}, nil
+	case RandomWaypoint:
+		return &Waypoint{base: b}, nil
+	case GaussMarkov:
+		return &GaussMarkovModel{base: b}, nil
+	case RPGM:
+		return &GroupModel{base: b}, nil
+	case Static:
+		return &StaticModel{base: b}, nil
+	case Trace:
+		return newTraceDriven(b)
+	}
+	return nil, fmt.Errorf("неизвестная модель подвижности: %q", cfg.MobilityModel)
+}
+
+// base - общие для всех моделей параметры и хранилище состояния
+type base struct {
+	cfg   *config.SimulatorConfig
+	rng   *rand.Rand
+	state State
+}
+
+func newBase(cfg *config.SimulatorConfig, rng *rand.Rand) base {
+	return base{
+		cfg:   cfg,
+		rng:   rng,
+		state: State{Nodes: make(map[int]NodeState), Groups: make(map[int]NodeState)},
+	}
+}
+
+func (b *base) Snapshot() State {
+	return State{Nodes: maps.Clone(b.state.Nodes), Groups: maps.Clone(b.state.Groups)}
+}
+
+func (b *base) Restore(st State) {
+	b.state = State{Nodes: maps.Clone(st.Nodes), Groups: maps.Clone(st.Groups)}
+	if b.state.Nodes == nil {
+		b.state.Nodes = make(map[int]NodeState)
+	}
+	if b.state.Groups == nil {
+		b.state.Groups = make(map[int]NodeState)
+	}
+}
+
+// clamp ограничивает точку границами поля и диапазоном высот
+func (b *base) clamp(p models.Point) models.Point {
+	return models.Point{
+		X: models.Clamp(p.X, 0, b.cfg.AreaWidth),
+		Y: models.Clamp(p.Y, 0, b.cfg.AreaHeight),
+		Z: models.Clamp(p.Z, b.cfg.AreaAltitudeMin, b.cfg.AreaAltitudeMax),
+	}
+}
+
+// altitudeRange - толщина слоя полета; 0 означает плоский сценарий
+func (b *base) altitudeRange() float64 {
+	return b.cfg.AreaAltitudeMax - b.cfg.AreaAltitudeMin
+}
+
+// randomPoint выбирает случайную точку поля. Высота разыгрывается только при
+// ненулевом диапазоне, чтобы плоские сценарии не меняли последовательность ГСЧ.
+func (b *base) randomPoint() models.Point {
+	p := models.Point{X: b.rng.Float64() * b.cfg.AreaWidth, Y: b.rng.Float64() * b.cfg.AreaHeight, Z: b.cfg.AreaAltitudeMin}
+	if h := b.altitudeRange(); h > 0 {
+		p.Z += b.rng.Float64() * h
+	}
+	return p
+}
+
+func (b *base) randomSpeed() float64 {
+	return b.cfg.MinSpeed + b.rng.Float64()*(b.cfg.MaxSpeed-b.cfg.MinSpeed)
+}
+
+// advanceWaypoint продвигает состояние Random Waypoint на dt секунд, начиная из pos.
+// Используется и для отдельных узлов, и для опорных точек групп RPGM.
+func (b *base) advanceWaypoint(st *NodeState, pos models.Point, dt float64) models.Point {
+	for dt > 0 {
+		if st.PauseLeft > 0 {
+			if st.PauseLeft >= dt {
+				st.PauseLeft -= dt
+				return pos
+			}
+			dt -= st.PauseLeft
+			st.PauseLeft = 0
+		}
+
+		dist := pos.Distance(st.Target)
+		if st.Speed <= 0 || dist == 0 {
+			// Вырожденная скорость: сразу выбираем новую путевую точку
+			st.Target = b.randomPoint()
+			st.Speed = b.randomSpeed()
+			if st.Speed <= 0 {
+				return pos
+			}
+			continue
+		}
+
+		travel := st.Speed * dt
+		if travel < dist {
+			ratio := travel / dist
+			return models.Point{
+				X: pos.X + (st.Target.X-pos.X)*ratio,
+				Y: pos.Y + (st.Target.Y-pos.Y)*ratio,
+				Z: pos.Z + (st.Target.Z-pos.Z)*ratio,
+			}
+		}
+
+		// Путевая точка достигнута: пауза и новая цель
+		dt -= dist / st.Speed
+		pos = st.Target
+		st.PauseLeft = b.cfg.PauseTime
+		st.Target = b.randomPoint()
+		st.Speed = b.randomSpeed()
+	}
+	return pos
+}
@@ -0,0 +1,201 @@
+// Файл: mobility/models.go
+package mobility
+
+import (
+	"drone_trust_sim/models"
+	"math"
+)
+
+// Jitter - исходная модель: на каждом шаге узел смещается на случайную величину
+// в пределах ±MaxSpeed*MobilityUpdateInterval/2 по каждой оси (по высоте - только
+// при заданном диапазоне высот).
+type Jitter struct {
+	base
+}
+
+func (m *Jitter) Init(node *models.DroneNode, now float64) {}
+
+func (m *Jitter) Move(node *models.DroneNode, now float64) {
+	step := m.cfg.MaxSpeed * m.cfg.MobilityUpdateInterval
+	node.Location.X += (m.rng.Float64() - 0.5) * step
+	node.Location.Y += (m.rng.Float64() - 0.5) * step
+	if m.altitudeRange() > 0 {
+		node.Location.Z += (m.rng.Float64() - 0.5) * step
+	}
+	node.Location = m.clamp(node.Location)
+}
+
+// Waypoint - Random Waypoint: движение к случайной точке со случайной скоростью
+// из [MinSpeed, MaxSpeed], затем пауза PauseTime и выбор новой точки.
+type Waypoint struct {
+	base
+}
+
+func (m *Waypoint) Init(node *models.DroneNode, now float64) {
+	m.state.Nodes[node.ID] = NodeState{Target: m.randomPoint(), Speed: m.randomSpeed(), LastUpdate: now}
+}
+
+func (m *Waypoint) Move(node *models.DroneNode, now float64) {
+	st := m.state.Nodes[node.ID]
+	node.Location = m.advanceWaypoint(&st, node.Location, now-st.LastUpdate)
+	st.LastUpdate = now
+	m.state.Nodes[node.ID] = st
+}
+
+// GaussMarkovModel - модель Гаусса-Маркова: скорость и направление коррелированы во времени
+// с параметром памяти GaussMarkovAlpha. Средняя скорость - середина [MinSpeed, MaxSpeed].
+// У границ поля среднее направление разворачивается к центру. При заданном диапазоне высот
+// вертикальная скорость меняется по тому же закону со средним, направленным к середине слоя.
+type GaussMarkovModel struct {
+	base
+}
+
+const gaussMarkovDirectionStdDev = math.Pi / 4 // Разброс направления при alpha = 0
+
+func (m *GaussMarkovModel) Init(node *models.DroneNode, now float64) {
+	m.state.Nodes[node.ID] = NodeState{Speed: m.meanSpeed(), Direction: m.rng.Float64() * 2 * math.Pi, LastUpdate: now}
+}
+
+func (m *GaussMarkovModel) meanSpeed() float64 {
+	return (m.cfg.MinSpeed + m.cfg.MaxSpeed) / 2
+}
+
+func (m *GaussMarkovModel) Move(node *models.DroneNode, now float64) {
+	st := m.state.Nodes[node.ID]
+	dt := now - st.LastUpdate
+	st.LastUpdate = now
+	if dt <= 0 {
+		m.state.Nodes[node.ID] = st
+		return
+	}
+
+	node.Location.X += st.Speed * math.Cos(st.Direction) * dt
+	node.Location.Y += st.Speed * math.Sin(st.Direction) * dt
+	node.Location.Z += st.Climb * dt
+	node.Location = m.clamp(node.Location)
+
+	alpha := m.cfg.GaussMarkovAlpha
+	noise := math.Sqrt(1 - alpha*alpha)
+	speedStdDev := (m.cfg.MaxSpeed - m.cfg.MinSpeed) / 2
+	st.Speed = alpha*st.Speed + (1-alpha)*m.meanSpeed() + noise*speedStdDev*m.rng.NormFloat64()
+	st.Speed = models.Clamp(st.Speed, m.cfg.MinSpeed, m.cfg.MaxSpeed)
+	st.Direction = alpha*st.Direction + (1-alpha)*m.meanDirection(node.Location, st.Direction) +
+		noise*gaussMarkovDirectionStdDev*m.rng.NormFloat64()
+	if m.altitudeRange() > 0 {
+		st.Climb = alpha*st.Climb + (1-alpha)*m.meanClimb(node.Location) + noise*speedStdDev*m.rng.NormFloat64()
+		st.Climb = models.Clamp(st.Climb, -m.cfg.MaxSpeed, m.cfg.MaxSpeed)
+	}
+	m.state.Nodes[node.ID] = st
+}
+
+// meanClimb возвращает нулевую вертикальную скорость, а вблизи нижней или верхней
+// границы слоя - среднюю скорость в сторону его середины
+func (m *GaussMarkovModel) meanClimb(pos models.Point) float64 {
+	margin := 0.1 * m.altitudeRange()
+	switch {
+	case pos.Z <= m.cfg.AreaAltitudeMin+margin:
+		return m.meanSpeed()
+	case pos.Z >= m.cfg.AreaAltitudeMax-margin:
+		return -m.meanSpeed()
+	}
+	return 0
+}
+
+// meanDirection возвращает текущее направление, а вблизи границы - направление к центру поля
+func (m *GaussMarkovModel) meanDirection(pos models.Point, current float64) float64 {
+	margin := 0.1 * math.Min(m.cfg.AreaWidth, m.cfg.AreaHeight)
+	if pos.X > margin && pos.X < m.cfg.AreaWidth-margin && pos.Y > margin && pos.Y < m.cfg.AreaHeight-margin {
+		return current
+	}
+	toCenter := math.Atan2(m.cfg.AreaHeight/2-pos.Y, m.cfg.AreaWidth/2-pos.X)
+	// Выбираем представление угла, ближайшее к текущему, чтобы усреднение не давало скачков
+	for toCenter-current > math.Pi {
+		toCenter -= 2 * math.Pi
+	}
+	for current-toCenter > math.Pi {
+		toCenter += 2 * math.Pi
+	}
+	return toCenter
+}
+
+// GroupModel - Reference Point Group Mobility: узлы объединены в группы по RPGMGroupSize
+// (по порядку ID). Опорная точка группы движется по Random Waypoint, а каждый узел
+// держится около нее со случайным смещением в пределах RPGMGroupRadius
+// (при заданном диапазоне высот - в шаре, иначе в круге).
+type GroupModel struct {
+	base
+}
+
+func (m *GroupModel) groupOf(node *models.DroneNode) int {
+	if m.cfg.RPGMGroupSize <= 0 {
+		return 0
+	}
+	return node.ID / m.cfg.RPGMGroupSize
+}
+
+func (m *GroupModel) Init(node *models.DroneNode, now float64) {
+	groupID := m.groupOf(node)
+	group, ok := m.state.Groups[groupID]
+	if !ok {
+		group = NodeState{Position: node.Location, Target: m.randomPoint(), Speed: m.randomSpeed(), LastUpdate: now}
+		m.state.Groups[groupID] = group
+	}
+	offset := m.randomOffset()
+	m.state.Nodes[node.ID] = NodeState{Offset: offset, LastUpdate: now}
+	node.Location = m.clamp(addOffset(group.Position, offset))
+}
+
+func addOffset(p, offset models.Point) models.Point {
+	return models.Point{X: p.X + offset.X, Y: p.Y + offset.Y, Z: p.Z + offset.Z}
+}
+
+// randomOffset выбирает равномерно распределенное смещение внутри круга
+// (или шара, если рой летит в слое) радиуса RPGMGroupRadius
+func (m *GroupModel) randomOffset() models.Point {
+	if m.altitudeRange() <= 0 {
+		r := m.cfg.RPGMGroupRadius * math.Sqrt(m.rng.Float64())
+		angle := m.rng.Float64() * 2 * math.Pi
+		return models.Point{X: r * math.Cos(angle), Y: r * math.Sin(angle)}
+	}
+	r := m.cfg.RPGMGroupRadius * math.Cbrt(m.rng.Float64())
+	angle := m.rng.Float64() * 2 * math.Pi
+	cosTheta := 2*m.rng.Float64() - 1
+	sinTheta := math.Sqrt(1 - cosTheta*cosTheta)
+	return models.Point{X: r * sinTheta * math.Cos(angle), Y: r * sinTheta * math.Sin(angle), Z: r * cosTheta}
+}
+
+func (m *GroupModel) Move(node *models.DroneNode, now float64) {
+	groupID := m.groupOf(node)
+	group := m.state.Groups[groupID]
+	if now > group.LastUpdate {
+		group.Position = m.advanceWaypoint(&group, group.Position, now-group.LastUpdate)
+		group.LastUpdate = now
+		m.state.Groups[groupID] = group
+	}
+
+	// Смещение узла внутри группы медленно блуждает, оставаясь в пределах радиуса
+	st := m.state.Nodes[node.ID]
+	st.Offset.X += (m.rng.Float64() - 0.5) * m.cfg.MinSpeed
+	st.Offset.Y += (m.rng.Float64() - 0.5) * m.cfg.MinSpeed
+	if m.altitudeRange() > 0 {
+		st.Offset.Z += (m.rng.Float64() - 0.5) * m.cfg.MinSpeed
+	}
+	if r := math.Hypot(math.Hypot(st.Offset.X, st.Offset.Y), st.Offset.Z); r > m.cfg.RPGMGroupRadius && r > 0 {
+		st.Offset.X *= m.cfg.RPGMGroupRadius / r
+		st.Offset.Y *= m.cfg.RPGMGroupRadius / r
+		st.Offset.Z *= m.cfg.RPGMGroupRadius / r
+	}
+	st.LastUpdate = now
+	m.state.Nodes[node.ID] = st
+
+	node.Location = m.clamp(addOffset(group.Position, st.Offset))
+}
+
+// StaticModel - неподвижные узлы
+type StaticModel struct {
+	base
+}
+
+func (m *StaticModel) Init(node *models.DroneNode, now float64) {}
+
+func (m *StaticModel) Move(node *models.DroneNode, now float64) {}
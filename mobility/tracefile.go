@@ -0,0 +1,262 @@
+// Файл: mobility/tracefile.go
+package mobility
+
+import (
+	"drone_trust_sim/models"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sample - точка записанной траектории
+type sample struct {
+	Time float64
+	Pos  models.Point
+}
+
+// TraceDriven - подвижность по записанным траекториям (логам полетов).
+// Положение между отсчетами интерполируется линейно, до первого отсчета и после
+// последнего узел стоит в крайней точке. Узлы без траектории остаются неподвижными.
+//
+// Поддерживаемые файлы (MobilityTraceFiles):
+//   - .csv: строки "time,id,x,y[,z]" в координатах поля; заголовок необязателен,
+//     в одном файле могут быть отсчеты нескольких дронов; без z узел летит
+//     на высоте AreaAltitudeMin;
+//   - .gpx: каждый трек <trk> - отдельный дрон, ID выдаются по порядку треков во всех
+//     GPX-файлах и продолжают нумерацию после дронов из CSV; широта/долгота
+//     проецируются в поле AreaWidth x AreaHeight с сохранением пропорций,
+//     высота <ele> отсчитывается от минимальной во всех треках, масштабируется
+//     так же и переносится в диапазон высот, время - от самой ранней метки.
+type TraceDriven struct {
+	base
+	tracks map[int][]sample
+}
+
+func newTraceDriven(b base) (*TraceDriven, error) {
+	m := &TraceDriven{base: b, tracks: make(map[int][]sample)}
+	var gpxTracks [][]gpxPoint
+	for _, path := range b.cfg.MobilityTraceFiles {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			if err := m.loadCSV(path); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		case ".gpx":
+			tracks, err := loadGPX(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			gpxTracks = append(gpxTracks, tracks...)
+		default:
+			return nil, fmt.Errorf("%s: неподдерживаемый формат траектории", path)
+		}
+	}
+	m.projectGPX(gpxTracks)
+
+	for id, track := range m.tracks {
+		sort.SliceStable(track, func(i, j int) bool { return track[i].Time < track[j].Time })
+		m.tracks[id] = track
+	}
+	return m, nil
+}
+
+func (m *TraceDriven) Init(node *models.DroneNode, now float64) {
+	if track, ok := m.tracks[node.ID]; ok {
+		node.Location = m.clamp(interpolate(track, now))
+	}
+}
+
+func (m *TraceDriven) Move(node *models.DroneNode, now float64) {
+	if track, ok := m.tracks[node.ID]; ok {
+		node.Location = m.clamp(interpolate(track, now))
+	}
+}
+
+// interpolate возвращает положение на траектории в момент t
+func interpolate(track []sample, t float64) models.Point {
+	if t <= track[0].Time {
+		return track[0].Pos
+	}
+	last := track[len(track)-1]
+	if t >= last.Time {
+		return last.Pos
+	}
+	i := sort.Search(len(track), func(i int) bool { return track[i].Time > t })
+	a, b := track[i-1], track[i]
+	if b.Time == a.Time {
+		return b.Pos
+	}
+	ratio := (t - a.Time) / (b.Time - a.Time)
+	return models.Point{
+		X: a.Pos.X + (b.Pos.X-a.Pos.X)*ratio,
+		Y: a.Pos.Y + (b.Pos.Y-a.Pos.Y)*ratio,
+		Z: a.Pos.Z + (b.Pos.Z-a.Pos.Z)*ratio,
+	}
+}
+
+func (m *TraceDriven) loadCSV(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	line := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line++
+		if len(record) < 4 || len(record) > 5 {
+			return fmt.Errorf("строка %d: ожидается time,id,x,y[,z]", line)
+		}
+		values := make([]float64, len(record))
+		for i, field := range record {
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			if line == 1 {
+				continue // Заголовок
+			}
+			return fmt.Errorf("строка %d: %w", line, err)
+		}
+		s := sample{Time: values[0], Pos: models.Point{X: values[2], Y: values[3]}}
+		if len(values) == 5 {
+			s.Pos.Z = values[4]
+		}
+		id := int(values[1])
+		m.tracks[id] = append(m.tracks[id], s)
+	}
+}
+
+// --- GPX ---
+
+type gpxPoint struct {
+	Lat, Lon, Ele float64
+	Time          time.Time
+}
+
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []struct {
+				Lat  float64 `xml:"lat,attr"`
+				Lon  float64 `xml:"lon,attr"`
+				Ele  float64 `xml:"ele"`
+				Time string  `xml:"time"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+func loadGPX(path string) ([][]gpxPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var doc gpxFile
+	if err := xml.NewDecoder(f).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var tracks [][]gpxPoint
+	for _, trk := range doc.Tracks {
+		var points []gpxPoint
+		for _, seg := range trk.Segments {
+			for _, p := range seg.Points {
+				ts, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+				if err != nil {
+					return nil, fmt.Errorf("точка без корректного <time>: %w", err)
+				}
+				points = append(points, gpxPoint{Lat: p.Lat, Lon: p.Lon, Ele: p.Ele, Time: ts})
+			}
+		}
+		if len(points) > 0 {
+			tracks = append(tracks, points)
+		}
+	}
+	return tracks, nil
+}
+
+// projectGPX переводит треки в координаты поля: равнопромежуточная проекция
+// относительно общего охватывающего прямоугольника, затем равномерное
+// масштабирование, чтобы все треки поместились в AreaWidth x AreaHeight.
+func (m *TraceDriven) projectGPX(tracks [][]gpxPoint) {
+	if len(tracks) == 0 {
+		return
+	}
+	const earthRadius = 6371000.0 // м
+
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	minEle := math.Inf(1)
+	start := tracks[0][0].Time
+	for _, track := range tracks {
+		for _, p := range track {
+			minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+			minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+			minEle = math.Min(minEle, p.Ele)
+			if p.Time.Before(start) {
+				start = p.Time
+			}
+		}
+	}
+
+	cosLat := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	toMeters := func(p gpxPoint) (float64, float64) {
+		x := (p.Lon - minLon) * math.Pi / 180 * earthRadius * cosLat
+		y := (p.Lat - minLat) * math.Pi / 180 * earthRadius
+		return x, y
+	}
+	widthM, heightM := toMeters(gpxPoint{Lat: maxLat, Lon: maxLon})
+	scale := 1.0
+	if widthM > 0 || heightM > 0 {
+		scale = math.Inf(1)
+		if widthM > 0 {
+			scale = math.Min(scale, m.cfg.AreaWidth/widthM)
+		}
+		if heightM > 0 {
+			scale = math.Min(scale, m.cfg.AreaHeight/heightM)
+		}
+	}
+
+	// ID продолжают нумерацию после дронов, уже заданных CSV-файлами
+	nextID := 0
+	for id := range m.tracks {
+		nextID = max(nextID, id+1)
+	}
+	for i, track := range tracks {
+		samples := make([]sample, len(track))
+		for j, p := range track {
+			x, y := toMeters(p)
+			samples[j] = sample{
+				Time: p.Time.Sub(start).Seconds(),
+				Pos: models.Point{
+					X: x * scale,
+					Y: y * scale,
+					Z: m.cfg.AreaAltitudeMin + (p.Ele-minEle)*scale,
+				},
+			}
+		}
+		m.tracks[nextID+i] = samples
+	}
+}
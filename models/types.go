@@ -6,12 +6,38 @@ import (
 	"sync"
 )
 
+// Point - положение в пространстве; Z - высота полета
 type Point struct {
-	X, Y float64
+	X, Y, Z float64
 }
 
+// Distance - евклидово расстояние в 3D с учетом разницы высот
 func (p Point) Distance(other Point) float64 {
-	return math.Sqrt(math.Pow(p.X-other.X, 2) + math.Pow(p.Y-other.Y, 2))
+	return math.Sqrt(math.Pow(p.X-other.X, 2) + math.Pow(p.Y-other.Y, 2) + math.Pow(p.Z-other.Z, 2))
+}
+
+// NodeStatus - состояние узла в сети
+type NodeStatus int
+
+const (
+	NodeActive  NodeStatus = iota // Узел работает
+	NodeDead                      // Батарея разряжена: узел не передает, не принимает и не участвует в кластерах
+	NodeLeft                      // Узел покинул рой (например, на подзарядку) и может вернуться
+	NodeCrashed                   // Необратимый отказ узла
+)
+
+func (st NodeStatus) String() string {
+	switch st {
+	case NodeActive:
+		return "active"
+	case NodeDead:
+		return "dead"
+	case NodeLeft:
+		return "left"
+	case NodeCrashed:
+		return "crashed"
+	}
+	return fmt.Sprintf("status_%d", int(st))
 }
 
 type DroneNode struct {
@@ -22,9 +48,11 @@ type DroneNode struct {
 	Mutex              sync.RWMutex
 
 	// Состояние
-	IsClusterHead bool
-	ClusterID     int
-	Energy        float64
+	IsClusterHead   bool
+	ClusterID       int
+	Energy          float64
+	Status          NodeStatus
+	IsGroundStation bool // Наземная станция управления (GCS): неподвижна, не расходует энергию, не бывает злонамеренной или CH
 
 	// Статистика для PoRS и метрик
 	PacketsSent         int
@@ -33,11 +61,17 @@ type DroneNode struct {
 	PacketsDroppedByMe  int
 	ConsensusRounds     int // Участие в консенсусе (для фактора RF)
 	ValidBlocksProposed int // Валидные блоки (для фактора RF)
-
-	// Канал для получения пакетов (для эмуляции)
-	PacketChannel chan *Packet
 }
 
+// PacketKind - назначение пакета
+type PacketKind int
+
+const (
+	PacketData      PacketKind = iota // Пользовательский трафик
+	PacketConsensus                   // Сообщение раунда консенсуса (только на уровне MAC)
+	PacketRouting                     // Служебное сообщение протокола маршрутизации (один хоп)
+)
+
 type Packet struct {
 	ID            int
 	SourceID      int
@@ -45,6 +79,44 @@ type Packet struct {
 	CreationTime  float64
 	Hops          int
 	IsAck         bool // Является ли пакет подтверждением
+	Kind          PacketKind
+	BlockID       int   // Для сообщений консенсуса - блок раунда, к которому они относятся
+	Size          int   // Размер, байт
+	Path          []int // Узлы, получившие пакет, начиная с источника (запись маршрута)
+
+	// Для подтверждения (IsAck) - подтверждаемый пакет данных и пройденный им маршрут
+	AckedID   int
+	AckedPath []int
+
+	// Состояние маршрутизации, которое пакет несет с собой
+	Routing     *RoutingMessage // Содержимое служебного пакета (PacketRouting)
+	SourceRoute []int           // Маршрут, заданный источником (DSR)
+	Perimeter   *Perimeter      // Режим обхода грани (GPSR); nil - жадная пересылка
+}
+
+// RoutingMessage - содержимое служебного пакета протокола маршрутизации.
+// Поля общие для всех протоколов; каждый тип сообщения использует только нужные.
+// Широковещательное сообщение получают несколько узлов сразу, поэтому изменять его нельзя.
+type RoutingMessage struct {
+	Type      string // RREQ, RREP, RERR, HELLO, TC
+	Origin    int    // Инициатор: источник RREQ, автор TC
+	Target    int    // Искомый узел RREQ/RREP
+	Seq       int    // Порядковый номер инициатора (или цели - в RREP)
+	TargetSeq int    // Последний известный номер цели (RREQ AODV)
+	ID        int    // Идентификатор запроса RREQ
+	HopCount  int
+	Route     []int // Накопленный (RREQ) или полный (RREP, RERR) маршрут DSR
+	Nodes     []int // Соседи в HELLO, селекторы в TC, недостижимые узлы или разорванная связь в RERR
+	MPR       []int // Выбранные ретрансляторы (HELLO OLSR)
+	Pos       Point // Положение отправителя (маяк GPSR)
+}
+
+// Perimeter - состояние пакета GPSR в режиме обхода грани по правилу правой руки
+type Perimeter struct {
+	Entry     Point  // Где пакет перешел в режим обхода (Lp)
+	Face      Point  // Точка входа на текущую грань (Lf)
+	FirstEdge [2]int // Первое ребро текущей грани (e0)
+	Prev      int    // Узел, от которого пакет пришел
 }
 
 type Block struct {
@@ -54,6 +126,11 @@ type Block struct {
 	// Здесь можно добавить транзакции, хэши и т.д. для более полной эмуляции
 }
 
+// Alive сообщает, работает ли узел
+func (n *DroneNode) Alive() bool {
+	return n.Status == NodeActive
+}
+
 func (n *DroneNode) String() string {
 	return fmt.Sprintf("Drone %d", n.ID)
 }
@@ -76,4 +153,33 @@ const (
 	Failure_OutOfRange                             // Потеря из-за разрыва связи
 	Failure_NoRoute                                // Не удалось найти следующий узел
 	Failure_PacketLoop                             // Превышен лимит хопов
+	Failure_Collision                              // Кадр разрушен коллизией на MAC-уровне
+	Failure_QueueOverflow                          // Очередь передачи узла переполнена
+	Failure_NodeDead                               // Отправитель или получатель не работает: разряжен, отказал или покинул рой
+	Failure_NoAck                                  // Источник не дождался сквозного подтверждения (свидетельство против маршрута)
 )
+
+// String возвращает машинно-читаемое имя исхода (используется в трассах и отчетах)
+func (r InteractionResult) String() string {
+	switch r {
+	case InteractionSuccess:
+		return "success"
+	case Failure_MaliciousDrop:
+		return "malicious_drop"
+	case Failure_OutOfRange:
+		return "out_of_range"
+	case Failure_NoRoute:
+		return "no_route"
+	case Failure_PacketLoop:
+		return "packet_loop"
+	case Failure_Collision:
+		return "collision"
+	case Failure_QueueOverflow:
+		return "queue_overflow"
+	case Failure_NodeDead:
+		return "node_dead"
+	case Failure_NoAck:
+		return "no_ack"
+	}
+	return fmt.Sprintf("result_%d", int(r))
+}
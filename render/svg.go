@@ -0,0 +1,201 @@
+// Файл: render/svg.go
+package render
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Node - узел на рисунке
+type Node struct {
+	ID        int
+	X, Y      float64
+	Alive     bool
+	Station   bool // Наземная станция
+	Head      bool // Глава кластера
+	Cluster   int
+	Malicious bool // Истинная роль узла
+	Flagged   bool // Рой считает узел злонамеренным (среднее входящее доверие ниже порога)
+}
+
+// Scene - состояние роя для рисунка
+type Scene struct {
+	Title         string
+	Time          float64
+	Width, Height float64 // Размеры поля (AreaWidth x AreaHeight)
+	Nodes         []Node
+	Links         [][2]int      // Пары соседей в радиусе связи
+	Clusters      map[int][]int // ID кластера -> ID участников
+}
+
+// Классы узлов: истинная роль против классификации по доверию.
+// Положительный исход - узел признан злонамеренным.
+const (
+	classTN = "Честный, доверенный (TN)"
+	classTP = "Злонамеренный, выявлен (TP)"
+	classFP = "Честный, ошибочно заподозрен (FP)"
+	classFN = "Злонамеренный, пропущен (FN)"
+)
+
+var classColors = map[string]string{
+	classTN: "#2e7d32",
+	classTP: "#1565c0",
+	classFP: "#f9a825",
+	classFN: "#c62828",
+}
+
+// Цвета кластеров по кругу
+var clusterPalette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"}
+
+const (
+	fieldPixels = 800.0 // Длинная сторона поля на рисунке, px
+	margin      = 40.0
+	legendWidth = 280.0
+)
+
+func class(n Node) string {
+	switch {
+	case n.Malicious && n.Flagged:
+		return classTP
+	case n.Malicious:
+		return classFN
+	case n.Flagged:
+		return classFP
+	}
+	return classTN
+}
+
+// SVG рисует сцену: поле, линии связи, оболочки кластеров, узлы, окрашенные по
+// классификации, выделенные CH и легенду. Ось Y направлена вверх, как в модели.
+func SVG(w io.Writer, sc *Scene) error {
+	scale := fieldPixels / max(sc.Width, sc.Height)
+	fw, fh := sc.Width*scale, sc.Height*scale
+	px := func(x float64) float64 { return margin + x*scale }
+	py := func(y float64) float64 { return margin + fh - y*scale }
+	byID := make(map[int]Node, len(sc.Nodes))
+	for _, n := range sc.Nodes {
+		byID[n.ID] = n
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" font-family="sans-serif" font-size="13">`+"\n",
+		2*margin+fw+legendWidth, 2*margin+fh)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="15">%s, t = %.1f с</text>`+"\n", margin, margin-14, html.EscapeString(sc.Title), sc.Time)
+	fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="#999"/>`+"\n", margin, margin, fw, fh)
+
+	// Оболочки кластеров
+	for _, id := range slices.Sorted(maps.Keys(sc.Clusters)) {
+		var pts [][2]float64
+		for _, m := range sc.Clusters[id] {
+			if n, ok := byID[m]; ok && n.Alive {
+				pts = append(pts, [2]float64{px(n.X), py(n.Y)})
+			}
+		}
+		if len(pts) < 3 {
+			continue
+		}
+		color := clusterPalette[id%len(clusterPalette)]
+		fmt.Fprintf(b, `<polygon points="%s" fill="%s" fill-opacity="0.08" stroke="%s" stroke-opacity="0.5" stroke-dasharray="4 3"/>`+"\n",
+			points(hull(pts)), color, color)
+	}
+
+	// Линии связи
+	fmt.Fprintf(b, `<g stroke="#bbb" stroke-width="0.6">`+"\n")
+	for _, l := range sc.Links {
+		a, c := byID[l[0]], byID[l[1]]
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`+"\n", px(a.X), py(a.Y), px(c.X), py(c.Y))
+	}
+	fmt.Fprintf(b, "</g>\n")
+
+	// Узлы: выбывшие серым, станции треугольником, CH квадратом с обводкой цвета кластера
+	for _, n := range sc.Nodes {
+		x, y := px(n.X), py(n.Y)
+		fill, stroke, width := classColors[class(n)], "#222", 1.0
+		if !n.Alive {
+			fill = "#ddd"
+		}
+		if n.Head {
+			stroke, width = clusterPalette[n.Cluster%len(clusterPalette)], 3
+		}
+		switch {
+		case n.Station:
+			fmt.Fprintf(b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="#555" stroke="#222"/>`+"\n", x, y-9, x+8, y+6, x-8, y+6)
+		case n.Head:
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="14" height="14" fill="%s" stroke="%s" stroke-width="%.0f"/>`+"\n", x-7, y-7, fill, stroke, width)
+		default:
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="5" fill="%s" stroke="%s" stroke-width="%.0f"/>`+"\n", x, y, fill, stroke, width)
+		}
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="9" fill="#444">%d</text>`+"\n", x+7, y-6, n.ID)
+	}
+
+	writeLegend(b, sc, margin+fw+20, margin)
+	fmt.Fprintf(b, "</svg>\n")
+	return b.Flush()
+}
+
+// writeLegend выводит обозначения и счетчики классов
+func writeLegend(b *bufio.Writer, sc *Scene, x, y float64) {
+	counts := make(map[string]int)
+	heads := 0
+	for _, n := range sc.Nodes {
+		if n.Alive && !n.Station {
+			counts[class(n)]++
+		}
+		if n.Head {
+			heads++
+		}
+	}
+	for _, c := range []string{classTN, classTP, classFP, classFN} {
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="6" fill="%s" stroke="#222"/>`+"\n", x+6, y, classColors[c])
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f">%s: %d</text>`+"\n", x+18, y+4, c, counts[c])
+		y += 22
+	}
+	fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="12" height="12" fill="white" stroke="#1f77b4" stroke-width="3"/>`+"\n", x, y-6)
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f">Глава кластера (CH): %d</text>`+"\n", x+18, y+4, heads)
+	y += 22
+	fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="6" fill="#ddd" stroke="#222"/>`+"\n", x+6, y)
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f">Выбывший узел</text>`+"\n", x+18, y+4)
+	y += 22
+	fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#bbb"/>`+"\n", x, y, x+12, y)
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f">Линия связи</text>`+"\n", x+18, y+4)
+	y += 22
+	fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="12" height="12" fill="#1f77b4" fill-opacity="0.08" stroke="#1f77b4" stroke-dasharray="4 3"/>`+"\n", x, y-6)
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f">Кластер (%d)</text>`+"\n", x+18, y+4, len(sc.Clusters))
+}
+
+// hull возвращает выпуклую оболочку точек (монотонная цепочка Эндрю)
+func hull(pts [][2]float64) [][2]float64 {
+	slices.SortFunc(pts, func(a, b [2]float64) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+	var h [][2]float64
+	for pass := 0; pass < 2; pass++ {
+		start := len(h)
+		for _, p := range pts {
+			for len(h) >= start+2 && cross(h[len(h)-2], h[len(h)-1], p) <= 0 {
+				h = h[:len(h)-1]
+			}
+			h = append(h, p)
+		}
+		h = h[:len(h)-1] // Последняя точка цепочки - первая точка следующей
+		slices.Reverse(pts)
+	}
+	return h
+}
+
+func points(pts [][2]float64) string {
+	var s []byte
+	for _, p := range pts {
+		s = fmt.Appendf(s, "%.1f,%.1f ", p[0], p[1])
+	}
+	return string(s)
+}
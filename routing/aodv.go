@@ -0,0 +1,198 @@
+// Файл: routing/aodv.go
+package routing
+
+import (
+	"drone_trust_sim/models"
+	"maps"
+	"slices"
+)
+
+// aodvRoute - запись таблицы маршрутов AODV
+type aodvRoute struct {
+	Next    int
+	Hops    int
+	Seq     int // Порядковый номер адресата, при котором маршрут получен
+	Expires float64
+	Valid   bool
+}
+
+// AODVState - таблицы маршрутов, порядковые номера и ожидающие пакеты AODV
+type AODVState struct {
+	Routes map[int]map[int]aodvRoute // Узел -> адресат -> маршрут
+	Seq    map[int]int               // Собственный порядковый номер узла
+	NextID map[int]int               // Последний ID RREQ, выпущенный узлом
+	Seen   map[requestKey]bool
+	Wait   buffer
+}
+
+// AODVRouter - AODV: маршрут ищется по требованию широковещательным RREQ, ответ RREP
+// возвращается по обратному пути и прокладывает прямой. Пакеты ждут маршрута в буфере
+// узла, на котором его не оказалось (в том числе транзитного - локальное восстановление).
+// Потеря кадра на линии делает маршруты через этого соседа недействительными (RERR).
+type AODVRouter struct {
+	net Network
+	st  *AODVState
+}
+
+func newAODV(net Network) *AODVRouter {
+	a := &AODVRouter{net: net, st: &AODVState{}}
+	a.st.init()
+	return a
+}
+
+// init создает недостающие таблицы (gob не передает пустые map)
+func (st *AODVState) init() {
+	if st.Routes == nil {
+		st.Routes = make(map[int]map[int]aodvRoute)
+	}
+	if st.Seq == nil {
+		st.Seq = make(map[int]int)
+	}
+	if st.NextID == nil {
+		st.NextID = make(map[int]int)
+	}
+	if st.Seen == nil {
+		st.Seen = make(map[requestKey]bool)
+	}
+	st.Wait.init()
+}
+
+func (a *AODVRouter) Start() {}
+
+func (a *AODVRouter) Route(node *models.DroneNode, packet *models.Packet) (*models.DroneNode, bool) {
+	if r, ok := a.valid(node.ID, packet.DestinationID); ok {
+		r.Expires = a.net.GetSimulationTime() + a.net.GetConfig().RouteTimeout
+		a.st.Routes[node.ID][packet.DestinationID] = r
+		return a.net.GetNodes()[r.Next], false
+	}
+	if a.st.Wait.hold(node, packet) {
+		a.discover(node, packet.DestinationID)
+	}
+	return nil, true
+}
+
+// valid возвращает действующий маршрут, следующий узел которого пропускает фильтр доверия
+func (a *AODVRouter) valid(nodeID, dest int) (aodvRoute, bool) {
+	r, ok := a.st.Routes[nodeID][dest]
+	if !ok || !r.Valid || r.Expires < a.net.GetSimulationTime() || !a.net.Trusted(nodeID, r.Next) {
+		return aodvRoute{}, false
+	}
+	return r, true
+}
+
+// update заносит маршрут к dest через соседа next, если он новее или короче действующего
+func (a *AODVRouter) update(nodeID, dest, next, hops, seq int) {
+	if r, ok := a.valid(nodeID, dest); ok && (seq < r.Seq || seq == r.Seq && hops >= r.Hops) {
+		return
+	}
+	if a.st.Routes[nodeID] == nil {
+		a.st.Routes[nodeID] = make(map[int]aodvRoute)
+	}
+	a.st.Routes[nodeID][dest] = aodvRoute{Next: next, Hops: hops, Seq: seq,
+		Expires: a.net.GetSimulationTime() + a.net.GetConfig().RouteTimeout, Valid: true}
+}
+
+// discover рассылает новый RREQ к dest и планирует ожидание ответа
+func (a *AODVRouter) discover(node *models.DroneNode, dest int) {
+	a.st.Seq[node.ID]++
+	a.st.NextID[node.ID]++
+	id := a.st.NextID[node.ID]
+	a.st.Seen[requestKey{node.ID, node.ID, id}] = true
+	a.st.Wait.search(a.net, node.ID, dest)
+	a.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgRREQ, Origin: node.ID, Target: dest,
+		Seq: a.st.Seq[node.ID], TargetSeq: a.st.Routes[node.ID][dest].Seq, ID: id})
+}
+
+func (a *AODVRouter) HandleControl(node *models.DroneNode, from int, msg *models.RoutingMessage) {
+	if !trustedFrom(a.net, node, from) {
+		return
+	}
+	switch msg.Type {
+	case MsgRREQ:
+		key := requestKey{node.ID, msg.Origin, msg.ID}
+		if a.st.Seen[key] {
+			return
+		}
+		a.st.Seen[key] = true
+		a.update(node.ID, from, from, 1, a.st.Routes[node.ID][from].Seq)
+		a.update(node.ID, msg.Origin, from, msg.HopCount+1, msg.Seq)
+		if node.ID == msg.Target {
+			a.st.Seq[node.ID] = max(a.st.Seq[node.ID]+1, msg.TargetSeq)
+			a.net.SendControl(node, from, &models.RoutingMessage{Type: MsgRREP, Origin: msg.Origin, Target: node.ID, Seq: a.st.Seq[node.ID]})
+			return
+		}
+		// Промежуточный узел отвечает сам, если знает достаточно свежий маршрут
+		if r, ok := a.valid(node.ID, msg.Target); ok && r.Seq >= msg.TargetSeq {
+			a.net.SendControl(node, from, &models.RoutingMessage{Type: MsgRREP, Origin: msg.Origin, Target: msg.Target, Seq: r.Seq, HopCount: r.Hops})
+			return
+		}
+		fwd := *msg
+		fwd.HopCount++
+		a.net.SendControl(node, -1, &fwd)
+
+	case MsgRREP:
+		a.update(node.ID, from, from, 1, a.st.Routes[node.ID][from].Seq)
+		a.update(node.ID, msg.Target, from, msg.HopCount+1, msg.Seq)
+		if _, waiting := a.st.Wait.Searching[pair{node.ID, msg.Target}]; waiting {
+			a.st.Wait.release(a.net, node, msg.Target, nil)
+		}
+		if node.ID == msg.Origin {
+			return
+		}
+		if r, ok := a.valid(node.ID, msg.Origin); ok {
+			fwd := *msg
+			fwd.HopCount++
+			a.net.SendControl(node, r.Next, &fwd)
+		}
+
+	case MsgRERR:
+		a.invalidate(node, func(dest int, r aodvRoute) bool {
+			return r.Next == from && slices.Contains(msg.Nodes, dest)
+		})
+	}
+}
+
+// invalidate делает недействительными маршруты узла, выбранные match, и сообщает
+// соседям список ставших недостижимыми адресатов
+func (a *AODVRouter) invalidate(node *models.DroneNode, match func(dest int, r aodvRoute) bool) {
+	var lost []int
+	table := a.st.Routes[node.ID]
+	for _, dest := range slices.Sorted(maps.Keys(table)) {
+		if r := table[dest]; r.Valid && match(dest, r) {
+			r.Valid = false
+			table[dest] = r
+			lost = append(lost, dest)
+		}
+	}
+	if len(lost) > 0 {
+		a.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgRERR, Origin: node.ID, Nodes: lost})
+	}
+}
+
+func (a *AODVRouter) LinkFailed(node *models.DroneNode, next int, packet *models.Packet) {
+	a.invalidate(node, func(_ int, r aodvRoute) bool { return r.Next == next })
+}
+
+func (a *AODVRouter) Timer(node *models.DroneNode, timer Timer) {
+	if timer.Kind == TimerDiscovery && a.st.Wait.expired(a.net, node, timer.Target) {
+		a.discover(node, timer.Target)
+	}
+}
+
+func (a *AODVRouter) NodeJoined(node *models.DroneNode) {}
+
+// NodeRemoved сбрасывает ожидающие пакеты узла и его таблицу маршрутов:
+// вернувшийся узел начинает с пустой таблицей
+func (a *AODVRouter) NodeRemoved(node *models.DroneNode) {
+	a.st.Wait.drop(a.net, node)
+	delete(a.st.Routes, node.ID)
+}
+
+func (a *AODVRouter) Snapshot() ProtocolState {
+	return ProtocolState{AODV: a.st}
+}
+
+func (a *AODVRouter) Restore(st ProtocolState) {
+	a.st = st.AODV
+	a.st.init()
+}
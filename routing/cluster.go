@@ -4,8 +4,12 @@ import (
 	"drone_trust_sim/config"
 	"drone_trust_sim/metrics"
 	"drone_trust_sim/models"
+	"drone_trust_sim/spatial"
 	"drone_trust_sim/trust"
+	"fmt"
+	"maps"
 	"math"
+	"slices"
 	"sync"
 )
 
@@ -14,16 +18,18 @@ type ClusterManager struct {
 	nodes         []*models.DroneNode
 	cfg           *config.SimulatorConfig
 	trustManager  *trust.Manager
+	index         *spatial.Grid               // Положения работающих узлов для поиска соседей
 	clusters      map[int][]*models.DroneNode // clusterID -> members
 	nodeToCluster map[int]int                 // nodeID -> clusterID
 	clusterHeads  map[int]*models.DroneNode   // clusterID -> CH
 }
 
-func NewClusterManager(nodes []*models.DroneNode, cfg *config.SimulatorConfig, tm *trust.Manager) *ClusterManager {
+func NewClusterManager(nodes []*models.DroneNode, cfg *config.SimulatorConfig, tm *trust.Manager, index *spatial.Grid) *ClusterManager {
 	return &ClusterManager{
 		nodes:         nodes,
 		cfg:           cfg,
 		trustManager:  tm,
+		index:         index,
 		clusters:      make(map[int][]*models.DroneNode),
 		nodeToCluster: make(map[int]int),
 		clusterHeads:  make(map[int]*models.DroneNode),
@@ -48,8 +54,8 @@ func (cm *ClusterManager) ReelectClusterHeads(currentTime float64, metrics *metr
 		var maxFinalScore float64 = -math.MaxFloat64
 
 		for _, candidate := range members {
-			if candidate.Energy < cm.cfg.EnergyMin {
-				continue
+			if candidate.IsGroundStation || candidate.Energy < cm.cfg.EnergyMin {
+				continue // Наземная станция в CH не выбирается
 			}
 
 			var score float64
@@ -97,7 +103,7 @@ func (cm *ClusterManager) formClusters() {
 	clusterCounter := 0
 
 	for _, node := range cm.nodes {
-		if visited[node.ID] {
+		if visited[node.ID] || !node.Alive() {
 			continue
 		}
 		clusterCounter++
@@ -112,8 +118,10 @@ func (cm *ClusterManager) formClusters() {
 			cm.clusters[clusterCounter] = append(cm.clusters[clusterCounter], currentNode)
 			cm.nodeToCluster[currentNode.ID] = clusterCounter
 
-			for _, neighbor := range cm.nodes {
-				if !visited[neighbor.ID] && currentNode.Location.Distance(neighbor.Location) <= cm.cfg.CommunicationRadius {
+			// Индекс возвращает узлы в радиусе в порядке ID - как и прежний полный перебор
+			for _, id := range cm.index.Within(currentNode.Location, cm.cfg.CommunicationRadius) {
+				neighbor := cm.nodes[id]
+				if !visited[neighbor.ID] && neighbor.Alive() {
 					visited[neighbor.ID] = true
 					queue = append(queue, neighbor)
 				}
@@ -128,7 +136,9 @@ func (cm *ClusterManager) updateRoles() {
 		n.ClusterID = -1
 	}
 
-	for clusterID, ch := range cm.clusterHeads {
+	// Порядок обхода фиксирован, иначе роли узлов зависели бы от итерации по map
+	for _, clusterID := range slices.Sorted(maps.Keys(cm.clusterHeads)) {
+		ch := cm.clusterHeads[clusterID]
 		ch.IsClusterHead = true
 		ch.ClusterID = clusterID
 		for _, member := range cm.clusters[clusterID] {
@@ -156,6 +166,33 @@ func calculateTopologicalFactor(candidate *models.DroneNode, members []*models.D
 	return models.Clamp(factor, 0.0, 1.0)
 }
 
+// AddNode добавляет в рой новый узел; в кластер он попадет на следующих перевыборах
+func (cm *ClusterManager) AddNode(node *models.DroneNode) {
+	cm.Lock()
+	defer cm.Unlock()
+	node.ClusterID = -1
+	cm.nodes = append(cm.nodes, node)
+}
+
+// RemoveNode исключает узел из его кластера до следующих перевыборов.
+// Если узел был главой, кластер остается без главы.
+func (cm *ClusterManager) RemoveNode(nodeID int) {
+	cm.Lock()
+	defer cm.Unlock()
+	clusterID, ok := cm.nodeToCluster[nodeID]
+	if !ok {
+		return
+	}
+	delete(cm.nodeToCluster, nodeID)
+	cm.clusters[clusterID] = slices.DeleteFunc(slices.Clone(cm.clusters[clusterID]), func(n *models.DroneNode) bool { return n.ID == nodeID })
+	if ch := cm.clusterHeads[clusterID]; ch != nil && ch.ID == nodeID {
+		delete(cm.clusterHeads, clusterID)
+	}
+	node := cm.nodes[nodeID]
+	node.IsClusterHead = false
+	node.ClusterID = -1
+}
+
 // Getters для безопасного доступа из других пакетов
 func (cm *ClusterManager) GetNodeClusterHead(nodeID int) *models.DroneNode {
 	cm.RLock()
@@ -183,3 +220,56 @@ func (cm *ClusterManager) GetClusters() map[int][]*models.DroneNode {
 	defer cm.RUnlock()
 	return cm.clusters
 }
+
+// State - сериализуемое состояние кластеризации (для контрольных точек)
+type State struct {
+	Clusters     map[int][]int // clusterID -> ID участников
+	ClusterHeads map[int]int   // clusterID -> ID главы
+}
+
+// Snapshot сохраняет разбиение на кластеры и главы кластеров в виде ID узлов
+func (cm *ClusterManager) Snapshot() State {
+	cm.RLock()
+	defer cm.RUnlock()
+	st := State{
+		Clusters:     make(map[int][]int, len(cm.clusters)),
+		ClusterHeads: make(map[int]int, len(cm.clusterHeads)),
+	}
+	for clusterID, members := range cm.clusters {
+		ids := make([]int, len(members))
+		for i, m := range members {
+			ids[i] = m.ID
+		}
+		st.Clusters[clusterID] = ids
+	}
+	for clusterID, ch := range cm.clusterHeads {
+		st.ClusterHeads[clusterID] = ch.ID
+	}
+	return st
+}
+
+// Restore восстанавливает кластеры по ID узлов. Роли узлов (IsClusterHead, ClusterID)
+// восстанавливаются вместе с самими узлами, поэтому здесь не пересчитываются.
+func (cm *ClusterManager) Restore(st State) error {
+	cm.Lock()
+	defer cm.Unlock()
+	cm.clusters = make(map[int][]*models.DroneNode, len(st.Clusters))
+	cm.nodeToCluster = make(map[int]int)
+	cm.clusterHeads = make(map[int]*models.DroneNode, len(st.ClusterHeads))
+	for clusterID, ids := range st.Clusters {
+		for _, id := range ids {
+			if id < 0 || id >= len(cm.nodes) {
+				return fmt.Errorf("кластер %d ссылается на несуществующий узел %d", clusterID, id)
+			}
+			cm.clusters[clusterID] = append(cm.clusters[clusterID], cm.nodes[id])
+			cm.nodeToCluster[id] = clusterID
+		}
+	}
+	for clusterID, id := range st.ClusterHeads {
+		if id < 0 || id >= len(cm.nodes) {
+			return fmt.Errorf("глава кластера %d - несуществующий узел %d", clusterID, id)
+		}
+		cm.clusterHeads[clusterID] = cm.nodes[id]
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Файл: routing/dsr.go
+package routing
+
+import (
+	"drone_trust_sim/models"
+	"slices"
+)
+
+// dsrCacheSize - сколько маршрутов хранит кэш одного узла; старые вытесняются первыми
+const dsrCacheSize = 64
+
+// DSRState - кэши маршрутов и ожидающие пакеты DSR
+type DSRState struct {
+	Cache  map[int][][]int // Узел -> известные маршруты, каждый начинается с самого узла
+	NextID map[int]int     // Последний ID RREQ, выпущенный узлом
+	Seen   map[requestKey]bool
+	Wait   buffer
+}
+
+// DSRRouter - DSR: источник вкладывает в пакет полный маршрут (SourceRoute), транзитные узлы
+// только следуют ему. Маршруты ищутся по требованию: RREQ накапливает пройденный путь,
+// цель или узел с подходящим маршрутом в кэше возвращает его в RREP по обратному пути.
+// Разрыв линии удаляет ее из кэшей и сообщается источнику сообщением RERR.
+// Фильтр доверия применяется источником ко всем узлам маршрута.
+type DSRRouter struct {
+	net Network
+	st  *DSRState
+}
+
+func newDSR(net Network) *DSRRouter {
+	d := &DSRRouter{net: net, st: &DSRState{}}
+	d.st.init()
+	return d
+}
+
+// init создает недостающие таблицы (gob не передает пустые map)
+func (st *DSRState) init() {
+	if st.Cache == nil {
+		st.Cache = make(map[int][][]int)
+	}
+	if st.NextID == nil {
+		st.NextID = make(map[int]int)
+	}
+	if st.Seen == nil {
+		st.Seen = make(map[requestKey]bool)
+	}
+	st.Wait.init()
+}
+
+func (d *DSRRouter) Start() {}
+
+func (d *DSRRouter) Route(node *models.DroneNode, packet *models.Packet) (*models.DroneNode, bool) {
+	nodes := d.net.GetNodes()
+	if packet.SourceRoute != nil {
+		i := slices.Index(packet.SourceRoute, node.ID)
+		if i < 0 || i+1 >= len(packet.SourceRoute) {
+			return nil, false
+		}
+		return nodes[packet.SourceRoute[i+1]], false
+	}
+	if route := d.lookup(node.ID, packet.DestinationID); route != nil {
+		packet.SourceRoute = route
+		return nodes[route[1]], false
+	}
+	if d.st.Wait.hold(node, packet) {
+		d.discover(node, packet.DestinationID)
+	}
+	return nil, true
+}
+
+// lookup возвращает кратчайший маршрут от узла к dest из его кэша, все транзитные
+// узлы которого проходят фильтр доверия, или nil
+func (d *DSRRouter) lookup(nodeID, dest int) []int {
+	var best []int
+	for _, route := range d.st.Cache[nodeID] {
+		i := slices.Index(route, dest)
+		if i < 1 || best != nil && i+1 >= len(best) {
+			continue
+		}
+		trusted := true
+		for _, hop := range route[1:i] {
+			if !d.net.Trusted(nodeID, hop) {
+				trusted = false
+				break
+			}
+		}
+		if trusted {
+			best = route[:i+1]
+		}
+	}
+	return slices.Clone(best)
+}
+
+// learn добавляет в кэш узла маршрут, начинающийся с него самого
+func (d *DSRRouter) learn(nodeID int, route []int) {
+	if len(route) < 2 || route[0] != nodeID {
+		return
+	}
+	cache := d.st.Cache[nodeID]
+	if slices.ContainsFunc(cache, func(r []int) bool { return slices.Equal(r, route) }) {
+		return
+	}
+	if len(cache) >= dsrCacheSize {
+		cache = slices.Delete(cache, 0, 1)
+	}
+	d.st.Cache[nodeID] = append(cache, slices.Clone(route))
+}
+
+// forget обрезает маршруты кэша узла перед разорванной линией a -> b
+func (d *DSRRouter) forget(nodeID, a, b int) {
+	cache := d.st.Cache[nodeID][:0]
+	for _, route := range d.st.Cache[nodeID] {
+		for i := 0; i+1 < len(route); i++ {
+			if route[i] == a && route[i+1] == b {
+				route = route[:i+1]
+				break
+			}
+		}
+		if len(route) >= 2 {
+			cache = append(cache, route)
+		}
+	}
+	d.st.Cache[nodeID] = cache
+}
+
+// discover рассылает новый RREQ к dest и планирует ожидание ответа
+func (d *DSRRouter) discover(node *models.DroneNode, dest int) {
+	d.st.NextID[node.ID]++
+	id := d.st.NextID[node.ID]
+	d.st.Seen[requestKey{node.ID, node.ID, id}] = true
+	d.st.Wait.search(d.net, node.ID, dest)
+	d.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgRREQ, Origin: node.ID, Target: dest, ID: id, Route: []int{node.ID}})
+}
+
+// reply отправляет RREP с полным маршрутом route назад, к предыдущему узлу
+func (d *DSRRouter) reply(node *models.DroneNode, route []int) {
+	i := slices.Index(route, node.ID)
+	d.net.SendControl(node, route[i-1], &models.RoutingMessage{Type: MsgRREP, Origin: route[0], Target: route[len(route)-1], Route: route})
+}
+
+func (d *DSRRouter) HandleControl(node *models.DroneNode, from int, msg *models.RoutingMessage) {
+	if !trustedFrom(d.net, node, from) {
+		return
+	}
+	switch msg.Type {
+	case MsgRREQ:
+		key := requestKey{node.ID, msg.Origin, msg.ID}
+		if d.st.Seen[key] || slices.Contains(msg.Route, node.ID) {
+			return
+		}
+		d.st.Seen[key] = true
+		route := append(slices.Clone(msg.Route), node.ID)
+		back := slices.Clone(route)
+		slices.Reverse(back)
+		d.learn(node.ID, back) // Линии считаются двунаправленными
+		if node.ID == msg.Target {
+			d.reply(node, route)
+			return
+		}
+		if cached := d.lookup(node.ID, msg.Target); cached != nil &&
+			!slices.ContainsFunc(cached[1:], func(id int) bool { return slices.Contains(route, id) }) {
+			d.reply(node, append(route, cached[1:]...))
+			return
+		}
+		d.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgRREQ, Origin: msg.Origin, Target: msg.Target, ID: msg.ID, Route: route})
+
+	case MsgRREP:
+		i := slices.Index(msg.Route, node.ID)
+		if i < 0 {
+			return
+		}
+		d.learn(node.ID, msg.Route[i:])
+		// Ожидающие пакеты уходят, только если есть маршрут, прошедший фильтр доверия
+		if _, waiting := d.st.Wait.Searching[pair{node.ID, msg.Target}]; waiting && d.lookup(node.ID, msg.Target) != nil {
+			d.st.Wait.release(d.net, node, msg.Target, nil)
+		}
+		if i > 0 {
+			d.net.SendControl(node, msg.Route[i-1], msg)
+		}
+
+	case MsgRERR:
+		d.forget(node.ID, msg.Nodes[0], msg.Nodes[1])
+		if i := slices.Index(msg.Route, node.ID); i > 0 {
+			d.net.SendControl(node, msg.Route[i-1], msg)
+		}
+	}
+}
+
+// LinkFailed удаляет линию из кэша и, если пакет шел по маршруту источника,
+// отправляет источнику RERR по пройденной части маршрута
+func (d *DSRRouter) LinkFailed(node *models.DroneNode, next int, packet *models.Packet) {
+	d.forget(node.ID, node.ID, next)
+	if packet.Kind != models.PacketData || packet.SourceRoute == nil {
+		return
+	}
+	if i := slices.Index(packet.SourceRoute, node.ID); i > 0 {
+		d.net.SendControl(node, packet.SourceRoute[i-1], &models.RoutingMessage{Type: MsgRERR, Origin: node.ID,
+			Nodes: []int{node.ID, next}, Route: slices.Clone(packet.SourceRoute[:i+1])})
+	}
+}
+
+func (d *DSRRouter) Timer(node *models.DroneNode, timer Timer) {
+	if timer.Kind == TimerDiscovery && d.st.Wait.expired(d.net, node, timer.Target) {
+		d.discover(node, timer.Target)
+	}
+}
+
+func (d *DSRRouter) NodeJoined(node *models.DroneNode) {}
+
+// NodeRemoved сбрасывает ожидающие пакеты и кэш узла
+func (d *DSRRouter) NodeRemoved(node *models.DroneNode) {
+	d.st.Wait.drop(d.net, node)
+	delete(d.st.Cache, node.ID)
+}
+
+func (d *DSRRouter) Snapshot() ProtocolState {
+	return ProtocolState{DSR: d.st}
+}
+
+func (d *DSRRouter) Restore(st ProtocolState) {
+	d.st = st.DSR
+	d.st.init()
+}
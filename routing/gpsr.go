@@ -0,0 +1,242 @@
+// Файл: routing/gpsr.go
+package routing
+
+import (
+	"drone_trust_sim/models"
+	"maps"
+	"math"
+	"slices"
+)
+
+// gpsrNeighbor - положение соседа из его последнего маяка
+type gpsrNeighbor struct {
+	Pos     models.Point
+	Expires float64
+}
+
+// GPSRState - таблицы соседей всех узлов
+type GPSRState struct {
+	Neighbors map[int]map[int]gpsrNeighbor // Узел -> сосед -> положение
+}
+
+// GPSRRouter - GPSR: узлы периодически рассылают маяки со своим положением. Пакет
+// пересылается жадно соседу, ближайшему к адресату; в локальном минимуме он переходит
+// в режим обхода грани планаризованного (габриэлева) графа соседей по правилу правой
+// руки и возвращается к жадной пересылке, как только оказывается ближе к адресату,
+// чем точка перехода. Положение адресата считается известным (служба местоположения).
+// Геометрия граней строится в горизонтальной проекции.
+type GPSRRouter struct {
+	net Network
+	st  *GPSRState
+}
+
+// neighborPos - сосед из таблицы узла
+type neighborPos struct {
+	ID  int
+	Pos models.Point
+}
+
+func newGPSR(net Network) *GPSRRouter {
+	g := &GPSRRouter{net: net, st: &GPSRState{}}
+	g.st.init()
+	return g
+}
+
+// init создает недостающие таблицы (gob не передает пустые map)
+func (st *GPSRState) init() {
+	if st.Neighbors == nil {
+		st.Neighbors = make(map[int]map[int]gpsrNeighbor)
+	}
+}
+
+func (g *GPSRRouter) Start() {
+	for _, n := range g.net.GetNodes() {
+		if n.Alive() {
+			g.NodeJoined(n)
+		}
+	}
+}
+
+// NodeJoined запускает периодические маяки узла со случайным сдвигом фазы
+func (g *GPSRRouter) NodeJoined(node *models.DroneNode) {
+	g.net.ScheduleTimer(node.ID, jitter(g.net, g.net.GetConfig().HelloInterval), Timer{Kind: TimerHello})
+}
+
+func (g *GPSRRouter) Timer(node *models.DroneNode, timer Timer) {
+	if timer.Kind != TimerHello {
+		return
+	}
+	g.net.ScheduleTimer(node.ID, g.net.GetConfig().HelloInterval, timer)
+	g.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgHello, Origin: node.ID, Pos: node.Location})
+}
+
+func (g *GPSRRouter) HandleControl(node *models.DroneNode, from int, msg *models.RoutingMessage) {
+	if msg.Type != MsgHello || !trustedFrom(g.net, node, from) {
+		return
+	}
+	if g.st.Neighbors[node.ID] == nil {
+		g.st.Neighbors[node.ID] = make(map[int]gpsrNeighbor)
+	}
+	g.st.Neighbors[node.ID][from] = gpsrNeighbor{Pos: msg.Pos,
+		Expires: g.net.GetSimulationTime() + holdFactor*g.net.GetConfig().HelloInterval}
+}
+
+// table возвращает действующих соседей узла, прошедших фильтр доверия, в порядке ID
+func (g *GPSRRouter) table(nodeID int) []neighborPos {
+	now := g.net.GetSimulationTime()
+	entries := g.st.Neighbors[nodeID]
+	maps.DeleteFunc(entries, func(_ int, n gpsrNeighbor) bool { return n.Expires < now })
+	var out []neighborPos
+	for _, id := range slices.Sorted(maps.Keys(entries)) {
+		if g.net.Trusted(nodeID, id) {
+			out = append(out, neighborPos{ID: id, Pos: entries[id].Pos})
+		}
+	}
+	return out
+}
+
+func (g *GPSRRouter) Route(node *models.DroneNode, packet *models.Packet) (*models.DroneNode, bool) {
+	nodes := g.net.GetNodes()
+	dest := nodes[packet.DestinationID].Location
+	table := g.table(node.ID)
+	if slices.ContainsFunc(table, func(n neighborPos) bool { return n.ID == packet.DestinationID }) {
+		packet.Perimeter = nil
+		return nodes[packet.DestinationID], false
+	}
+
+	p := packet.Perimeter
+	if p != nil && node.Location.Distance(dest) < p.Entry.Distance(dest) {
+		p, packet.Perimeter = nil, nil // Выход из локального минимума
+	}
+	if p == nil {
+		best, bestDist := -1, node.Location.Distance(dest)
+		for _, n := range table {
+			if d := n.Pos.Distance(dest); d < bestDist {
+				best, bestDist = n.ID, d
+			}
+		}
+		if best >= 0 {
+			return nodes[best], false
+		}
+		// Локальный минимум: первое ребро против часовой стрелки от направления на адресата
+		next := rightHand(node.Location, bearing(node.Location, dest), planarize(node.Location, table))
+		if next == nil {
+			return nil, false
+		}
+		packet.Perimeter = &models.Perimeter{Entry: node.Location, Face: node.Location,
+			FirstEdge: [2]int{node.ID, next.ID}, Prev: node.ID}
+		return nodes[next.ID], false
+	}
+
+	// Обход грани: следующее ребро против часовой стрелки от ребра, по которому пакет пришел.
+	// Положение предыдущего хопа передается в заголовке пакета.
+	planar := planarize(node.Location, table)
+	next := rightHand(node.Location, bearing(node.Location, nodes[p.Prev].Location), planar)
+	changed := false
+	// Ребро, пересекающее отрезок от точки перехода до адресата ближе к адресату,
+	// чем текущая точка входа, ведет на следующую грань
+	for next != nil {
+		cross, ok := intersect(node.Location, next.Pos, p.Entry, dest)
+		if !ok || planeDistance(cross, dest) >= planeDistance(p.Face, dest) {
+			break
+		}
+		p.Face = cross
+		next = rightHand(node.Location, bearing(node.Location, next.Pos), planar)
+		changed = true
+	}
+	if next == nil {
+		return nil, false
+	}
+	edge := [2]int{node.ID, next.ID}
+	if changed {
+		p.FirstEdge = edge
+	} else if edge == p.FirstEdge {
+		return nil, false // Грань обойдена целиком: адресат недостижим
+	}
+	p.Prev = node.ID
+	return nodes[next.ID], false
+}
+
+// planarize оставляет ребра габриэлева графа: сосед v сохраняется, если внутри окружности
+// с диаметром (u, v) нет другого соседа
+func planarize(u models.Point, table []neighborPos) []neighborPos {
+	var out []neighborPos
+	for _, v := range table {
+		duv := planeDistance(u, v.Pos)
+		keep := true
+		for _, w := range table {
+			if w.ID == v.ID {
+				continue
+			}
+			if dw, dv := planeDistance(u, w.Pos), planeDistance(v.Pos, w.Pos); dw*dw+dv*dv < duv*duv {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// rightHand возвращает соседа, первого против часовой стрелки от направления ref;
+// сосед точно в направлении ref выбирается последним
+func rightHand(u models.Point, ref float64, planar []neighborPos) *neighborPos {
+	var best *neighborPos
+	bestAngle := math.Inf(1)
+	for i := range planar {
+		a := math.Mod(bearing(u, planar[i].Pos)-ref+2*math.Pi, 2*math.Pi)
+		if a <= 0 {
+			a = 2 * math.Pi
+		}
+		if a < bestAngle {
+			best, bestAngle = &planar[i], a
+		}
+	}
+	return best
+}
+
+// bearing - направление от a на b в горизонтальной плоскости, рад
+func bearing(a, b models.Point) float64 {
+	return math.Atan2(b.Y-a.Y, b.X-a.X)
+}
+
+func planeDistance(a, b models.Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+// intersect находит точку пересечения отрезков (a, b) и (c, d) в горизонтальной плоскости,
+// не считая общих концов
+func intersect(a, b, c, d models.Point) (models.Point, bool) {
+	rx, ry := b.X-a.X, b.Y-a.Y
+	sx, sy := d.X-c.X, d.Y-c.Y
+	den := rx*sy - ry*sx
+	if den == 0 {
+		return models.Point{}, false
+	}
+	t := ((c.X-a.X)*sy - (c.Y-a.Y)*sx) / den
+	u := ((c.X-a.X)*ry - (c.Y-a.Y)*rx) / den
+	if t <= 0 || t > 1 || u < 0 || u > 1 {
+		return models.Point{}, false
+	}
+	return models.Point{X: a.X + t*rx, Y: a.Y + t*ry, Z: c.Z + u*(d.Z-c.Z)}, true
+}
+
+// LinkFailed удаляет соседа до его следующего маяка
+func (g *GPSRRouter) LinkFailed(node *models.DroneNode, next int, packet *models.Packet) {
+	delete(g.st.Neighbors[node.ID], next)
+}
+
+func (g *GPSRRouter) NodeRemoved(node *models.DroneNode) {
+	delete(g.st.Neighbors, node.ID)
+}
+
+func (g *GPSRRouter) Snapshot() ProtocolState {
+	return ProtocolState{GPSR: g.st}
+}
+
+func (g *GPSRRouter) Restore(st ProtocolState) {
+	g.st = st.GPSR
+	g.st.init()
+}
@@ -0,0 +1,139 @@
+package routing
+
+import (
+	"drone_trust_sim/config"
+	"drone_trust_sim/models"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// testNetwork - неподвижная сеть с радиусом связи radius и полным доверием
+type testNetwork struct {
+	cfg    config.SimulatorConfig
+	nodes  []*models.DroneNode
+	radius float64
+}
+
+func newTestNetwork(radius float64, pos ...models.Point) *testNetwork {
+	net := &testNetwork{cfg: config.SimulatorConfig{HelloInterval: 2}, radius: radius}
+	for i, p := range pos {
+		net.nodes = append(net.nodes, &models.DroneNode{ID: i, Location: p, Status: models.NodeActive})
+	}
+	return net
+}
+
+func (n *testNetwork) GetConfig() *config.SimulatorConfig                         { return &n.cfg }
+func (n *testNetwork) GetNodes() []*models.DroneNode                              { return n.nodes }
+func (n *testNetwork) GetRand() *rand.Rand                                        { return rand.New(rand.NewPCG(1, 2)) }
+func (n *testNetwork) GetSimulationTime() float64                                 { return 0 }
+func (n *testNetwork) GetNodeClusterHead(int) *models.DroneNode                   { return nil }
+func (n *testNetwork) Trusted(int, int) bool                                      { return true }
+func (n *testNetwork) ScheduleTimer(int, float64, Timer)                          {}
+func (n *testNetwork) Forward(*models.DroneNode, *models.Packet)                  {}
+func (n *testNetwork) SendControl(*models.DroneNode, int, *models.RoutingMessage) {}
+func (n *testNetwork) DropPacket(*models.DroneNode, *models.Packet, models.InteractionResult) {
+}
+
+func (n *testNetwork) IsNeighbor(a, b *models.DroneNode) bool {
+	return a.ID != b.ID && a.Location.Distance(b.Location) <= n.radius
+}
+
+func (n *testNetwork) Neighbors(node *models.DroneNode) []*models.DroneNode {
+	var out []*models.DroneNode
+	for _, other := range n.nodes {
+		if n.IsNeighbor(node, other) {
+			out = append(out, other)
+		}
+	}
+	return out
+}
+
+// newTestGPSR создает GPSR, в котором каждый узел уже получил маяки всех соседей
+func newTestGPSR(net *testNetwork) *GPSRRouter {
+	g := newGPSR(net)
+	for _, node := range net.nodes {
+		for _, nb := range net.Neighbors(node) {
+			g.HandleControl(node, nb.ID, &models.RoutingMessage{Type: MsgHello, Origin: nb.ID, Pos: nb.Location})
+		}
+	}
+	return g
+}
+
+// walk ведет пакет от src к dst; возвращает пройденные узлы и признак доставки
+func walk(t *testing.T, g *GPSRRouter, net *testNetwork, src, dst int) ([]int, bool) {
+	t.Helper()
+	packet := &models.Packet{SourceID: src, DestinationID: dst, Path: []int{src}}
+	node := net.nodes[src]
+	for range 50 {
+		if node.ID == dst {
+			return packet.Path, true
+		}
+		next, held := g.Route(node, packet)
+		if held {
+			t.Fatalf("GPSR задержал пакет на узле %d", node.ID)
+		}
+		if next == nil {
+			return packet.Path, false
+		}
+		node = next
+		packet.Path = append(packet.Path, node.ID)
+	}
+	t.Fatalf("пакет не остановился: %v", packet.Path)
+	return nil, false
+}
+
+// Источник 0 - локальный минимум: его соседи (висячий 1 и 2) дальше от адресата 7,
+// чем он сам. Пакет обходит пустоту по грани снизу, заходя в висячий узел и
+// возвращаясь через источник.
+func TestGPSRRoutesAroundVoid(t *testing.T) {
+	net := newTestNetwork(70,
+		models.Point{X: 0, Y: 0},      // 0: источник
+		models.Point{X: -40, Y: 20},   // 1: висячий узел
+		models.Point{X: -10, Y: -50},  // 2
+		models.Point{X: 40, Y: -90},   // 3
+		models.Point{X: 100, Y: -100}, // 4
+		models.Point{X: 160, Y: -80},  // 5
+		models.Point{X: 190, Y: -40},  // 6
+		models.Point{X: 200, Y: 0},    // 7: адресат
+	)
+	path, ok := walk(t, newTestGPSR(net), net, 0, 7)
+	if !ok {
+		t.Fatalf("пакет не доставлен: %v", path)
+	}
+	if want := []int{0, 1, 0, 2, 3, 4, 5, 6, 7}; !slices.Equal(path, want) {
+		t.Errorf("маршрут %v, ожидался %v", path, want)
+	}
+}
+
+// Адресат вне связной части сети: обойдя грань целиком, пакет теряется
+func TestGPSRDropsAfterFullFace(t *testing.T) {
+	net := newTestNetwork(70,
+		models.Point{X: 0, Y: 0},
+		models.Point{X: -50, Y: 0},
+		models.Point{X: 300, Y: 0},
+	)
+	path, ok := walk(t, newTestGPSR(net), net, 0, 2)
+	if ok {
+		t.Fatalf("пакет доставлен недостижимому адресату: %v", path)
+	}
+	if want := []int{0, 1, 0}; !slices.Equal(path, want) {
+		t.Errorf("маршрут %v, ожидался %v", path, want)
+	}
+}
+
+// Без пустоты пакет идет жадно и не переходит в режим обхода грани
+func TestGPSRGreedy(t *testing.T) {
+	net := newTestNetwork(70,
+		models.Point{X: 0, Y: 0},
+		models.Point{X: 60, Y: 10},
+		models.Point{X: 50, Y: -20},
+		models.Point{X: 120, Y: 0},
+	)
+	g := newTestGPSR(net)
+	packet := &models.Packet{SourceID: 0, DestinationID: 3, Path: []int{0}}
+	next, _ := g.Route(net.nodes[0], packet)
+	if next == nil || next.ID != 1 || packet.Perimeter != nil {
+		t.Fatalf("ожидалась жадная пересылка узлу 1, получено %v, обход грани %v", next, packet.Perimeter)
+	}
+}
@@ -0,0 +1,84 @@
+// Файл: routing/greedy.go
+package routing
+
+import "drone_trust_sim/models"
+
+// Greedy - исходная маршрутизация симулятора: прямая доставка соседу, иначе жадный шаг
+// к адресату (внутри кластера) или к главе его кластера, в крайнем случае - к своему CH.
+// Служебного трафика нет: соседи и их положения считаются известными.
+type Greedy struct {
+	net Network
+}
+
+func (g *Greedy) Route(sender *models.DroneNode, packet *models.Packet) (*models.DroneNode, bool) {
+	destinationNode := g.net.GetNodes()[packet.DestinationID]
+
+	// Шаг 1: Прямая доставка, если возможно. Это самый высокий приоритет.
+	if g.net.IsNeighbor(sender, destinationNode) {
+		return destinationNode, false
+	}
+
+	// Шаг 2: Определяем, является ли маршрутизация внутри- или межкластерной
+	senderClusterID := sender.ClusterID
+	destClusterID := destinationNode.ClusterID
+
+	var routingTarget *models.DroneNode // Точка, к которой мы стремимся на этом шаге
+	isInterCluster := false
+
+	if senderClusterID != -1 && senderClusterID == destClusterID {
+		// --- ВНУТРИКЛАСТЕРНАЯ МАРШРУТИЗАЦИЯ ---
+		routingTarget = destinationNode
+	} else {
+		// --- МЕЖКЛАСТЕРНАЯ МАРШРУТИЗАЦИЯ ---
+		isInterCluster = true
+		destCH := g.net.GetNodeClusterHead(destinationNode.ID)
+		if destCH == nil {
+			// Если у цели нет CH, пытаемся идти напрямую к цели
+			routingTarget = destinationNode
+		} else {
+			routingTarget = destCH
+		}
+	}
+
+	// Шаг 3: Жадный поиск лучшего следующего узла, который ближе к routingTarget
+	var bestNextHop *models.DroneNode
+	minDistToTarget := sender.Location.Distance(routingTarget.Location)
+
+	for _, potentialHop := range g.net.Neighbors(sender) {
+		if potentialHop.ID == destinationNode.ID {
+			continue
+		}
+
+		if !g.net.Trusted(sender.ID, potentialHop.ID) {
+			continue
+		}
+
+		distFromHopToTarget := potentialHop.Location.Distance(routingTarget.Location)
+		if distFromHopToTarget < minDistToTarget {
+			minDistToTarget = distFromHopToTarget
+			bestNextHop = potentialHop
+		}
+	}
+
+	// Шаг 4: Выбор
+	if bestNextHop != nil {
+		return bestNextHop, false
+	}
+	if isInterCluster {
+		// Если не нашли "транзитный" узел, попробуем отправить напрямую Главе своего кластера в надежде, что он знает путь
+		senderCH := g.net.GetNodeClusterHead(sender.ID)
+		if senderCH != nil && senderCH.ID != sender.ID {
+			return senderCH, false
+		}
+	}
+	return nil, false
+}
+
+func (g *Greedy) Start()                                                       {}
+func (g *Greedy) HandleControl(*models.DroneNode, int, *models.RoutingMessage) {}
+func (g *Greedy) LinkFailed(*models.DroneNode, int, *models.Packet)            {}
+func (g *Greedy) Timer(*models.DroneNode, Timer)                               {}
+func (g *Greedy) NodeJoined(*models.DroneNode)                                 {}
+func (g *Greedy) NodeRemoved(*models.DroneNode)                                {}
+func (g *Greedy) Snapshot() ProtocolState                                      { return ProtocolState{} }
+func (g *Greedy) Restore(ProtocolState)                                        {}
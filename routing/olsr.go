@@ -0,0 +1,285 @@
+// Файл: routing/olsr.go
+package routing
+
+import (
+	"drone_trust_sim/models"
+	"maps"
+	"slices"
+)
+
+// holdFactor - во сколько периодов рассылки запись о соседе или топологии остается действительной
+const holdFactor = 3
+
+// olsrLink - сосед узла, известный по его HELLO
+type olsrLink struct {
+	Neighbors []int // Соседи соседа, перечисленные в его HELLO
+	Symmetric bool  // Сосед слышит узел (узел есть в его HELLO)
+	Selector  bool  // Сосед выбрал узел своим MPR
+	Expires   float64
+}
+
+// olsrTopology - объявленные в TC связи узла-автора с его MPR-селекторами
+type olsrTopology struct {
+	Seq       int
+	Selectors []int
+	Expires   float64
+}
+
+// OLSRState - таблицы соседей, топологии и выбранные MPR всех узлов
+type OLSRState struct {
+	Links    map[int]map[int]olsrLink     // Узел -> сосед -> связь
+	Topology map[int]map[int]olsrTopology // Узел -> автор TC -> объявленные связи
+	MPR      map[int][]int                // Ретрансляторы, выбранные узлом
+	TCSeq    map[int]int                  // Последний номер TC, выпущенный узлом
+}
+
+// OLSRRouter - OLSR: узлы периодически рассылают HELLO (соседи и выбранные MPR) и TC
+// (свои MPR-селекторы), которые ретранслируют только MPR. По этим таблицам каждый узел
+// знает топологию заранее и прокладывает кратчайший по числу хопов путь; при отсутствии
+// пути пакет сразу теряется. Фильтр доверия исключает узлы из выбора MPR и из путей.
+type OLSRRouter struct {
+	net Network
+	st  *OLSRState
+}
+
+func newOLSR(net Network) *OLSRRouter {
+	o := &OLSRRouter{net: net, st: &OLSRState{}}
+	o.st.init()
+	return o
+}
+
+// init создает недостающие таблицы (gob не передает пустые map)
+func (st *OLSRState) init() {
+	if st.Links == nil {
+		st.Links = make(map[int]map[int]olsrLink)
+	}
+	if st.Topology == nil {
+		st.Topology = make(map[int]map[int]olsrTopology)
+	}
+	if st.MPR == nil {
+		st.MPR = make(map[int][]int)
+	}
+	if st.TCSeq == nil {
+		st.TCSeq = make(map[int]int)
+	}
+}
+
+func (o *OLSRRouter) Start() {
+	for _, n := range o.net.GetNodes() {
+		if n.Alive() {
+			o.NodeJoined(n)
+		}
+	}
+}
+
+// NodeJoined запускает периодические HELLO и TC узла со случайным сдвигом фазы
+func (o *OLSRRouter) NodeJoined(node *models.DroneNode) {
+	cfg := o.net.GetConfig()
+	o.net.ScheduleTimer(node.ID, jitter(o.net, cfg.HelloInterval), Timer{Kind: TimerHello})
+	o.net.ScheduleTimer(node.ID, jitter(o.net, cfg.TCInterval), Timer{Kind: TimerTC})
+}
+
+func (o *OLSRRouter) Timer(node *models.DroneNode, timer Timer) {
+	cfg := o.net.GetConfig()
+	switch timer.Kind {
+	case TimerHello:
+		o.net.ScheduleTimer(node.ID, cfg.HelloInterval, timer)
+		o.sendHello(node)
+	case TimerTC:
+		o.net.ScheduleTimer(node.ID, cfg.TCInterval, timer)
+		o.sendTC(node)
+	}
+}
+
+// links возвращает действующих соседей узла в порядке ID, попутно удаляя устаревшие записи
+func (o *OLSRRouter) links(nodeID int) []int {
+	now := o.net.GetSimulationTime()
+	table := o.st.Links[nodeID]
+	maps.DeleteFunc(table, func(_ int, l olsrLink) bool { return l.Expires < now })
+	return slices.Sorted(maps.Keys(table))
+}
+
+func (o *OLSRRouter) sendHello(node *models.DroneNode) {
+	o.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgHello, Origin: node.ID,
+		Nodes: o.links(node.ID), MPR: slices.Clone(o.st.MPR[node.ID])})
+}
+
+// sendTC объявляет MPR-селекторы узла; узел, которого никто не выбрал, TC не рассылает
+func (o *OLSRRouter) sendTC(node *models.DroneNode) {
+	var selectors []int
+	for _, id := range o.links(node.ID) {
+		if o.st.Links[node.ID][id].Selector {
+			selectors = append(selectors, id)
+		}
+	}
+	if len(selectors) == 0 {
+		return
+	}
+	o.st.TCSeq[node.ID]++
+	o.net.SendControl(node, -1, &models.RoutingMessage{Type: MsgTC, Origin: node.ID, Seq: o.st.TCSeq[node.ID], Nodes: selectors})
+}
+
+func (o *OLSRRouter) HandleControl(node *models.DroneNode, from int, msg *models.RoutingMessage) {
+	if !trustedFrom(o.net, node, from) {
+		return
+	}
+	now := o.net.GetSimulationTime()
+	cfg := o.net.GetConfig()
+	switch msg.Type {
+	case MsgHello:
+		if o.st.Links[node.ID] == nil {
+			o.st.Links[node.ID] = make(map[int]olsrLink)
+		}
+		symmetric := slices.Contains(msg.Nodes, node.ID)
+		o.st.Links[node.ID][from] = olsrLink{
+			Neighbors: slices.Clone(msg.Nodes),
+			Symmetric: symmetric,
+			Selector:  symmetric && slices.Contains(msg.MPR, node.ID),
+			Expires:   now + holdFactor*cfg.HelloInterval,
+		}
+		o.selectMPR(node.ID)
+
+	case MsgTC:
+		link, ok := o.st.Links[node.ID][from]
+		if msg.Origin == node.ID || !ok || !link.Symmetric || link.Expires < now {
+			return
+		}
+		if t, ok := o.st.Topology[node.ID][msg.Origin]; ok && t.Seq >= msg.Seq && t.Expires >= now {
+			return // Уже получен
+		}
+		if o.st.Topology[node.ID] == nil {
+			o.st.Topology[node.ID] = make(map[int]olsrTopology)
+		}
+		o.st.Topology[node.ID][msg.Origin] = olsrTopology{Seq: msg.Seq, Selectors: slices.Clone(msg.Nodes),
+			Expires: now + holdFactor*cfg.TCInterval}
+		// Ретранслируют только MPR отправителя
+		if link.Selector {
+			o.net.SendControl(node, -1, msg)
+		}
+	}
+}
+
+// selectMPR выбирает ретрансляторы узла: минимальный (жадно) набор симметричных соседей,
+// через которые достижимы все соседи второго уровня
+func (o *OLSRRouter) selectMPR(nodeID int) {
+	now := o.net.GetSimulationTime()
+	table := o.st.Links[nodeID]
+	var oneHop []int
+	for _, id := range slices.Sorted(maps.Keys(table)) {
+		if l := table[id]; l.Symmetric && l.Expires >= now && o.net.Trusted(nodeID, id) {
+			oneHop = append(oneHop, id)
+		}
+	}
+	uncovered := make(map[int]bool)
+	for _, id := range oneHop {
+		for _, two := range table[id].Neighbors {
+			if two != nodeID && !slices.Contains(oneHop, two) {
+				uncovered[two] = true
+			}
+		}
+	}
+	var mpr []int
+	for len(uncovered) > 0 {
+		best, bestCover := -1, 0
+		for _, id := range oneHop {
+			cover := 0
+			for _, two := range table[id].Neighbors {
+				if uncovered[two] {
+					cover++
+				}
+			}
+			if cover > bestCover {
+				best, bestCover = id, cover
+			}
+		}
+		if best < 0 {
+			break
+		}
+		mpr = append(mpr, best)
+		for _, two := range table[best].Neighbors {
+			delete(uncovered, two)
+		}
+	}
+	slices.Sort(mpr)
+	o.st.MPR[nodeID] = mpr
+}
+
+// Route ищет кратчайший путь в топологии, известной узлу: его симметричные соседи,
+// их соседи из HELLO и связи из действующих TC
+func (o *OLSRRouter) Route(node *models.DroneNode, packet *models.Packet) (*models.DroneNode, bool) {
+	now := o.net.GetSimulationTime()
+	dest := packet.DestinationID
+	graph := make(map[int][]int)
+	link := func(a, b int) {
+		graph[a] = append(graph[a], b)
+		graph[b] = append(graph[b], a)
+	}
+	for _, id := range o.links(node.ID) {
+		l := o.st.Links[node.ID][id]
+		if !l.Symmetric {
+			continue
+		}
+		graph[node.ID] = append(graph[node.ID], id)
+		for _, two := range l.Neighbors {
+			link(id, two)
+		}
+	}
+	for _, origin := range slices.Sorted(maps.Keys(o.st.Topology[node.ID])) {
+		t := o.st.Topology[node.ID][origin]
+		if t.Expires < now {
+			delete(o.st.Topology[node.ID], origin)
+			continue
+		}
+		for _, sel := range t.Selectors {
+			link(origin, sel)
+		}
+	}
+
+	// Поиск в ширину; first - первый хоп пути к каждому достигнутому узлу
+	first := map[int]int{node.ID: -1}
+	queue := []int{node.ID}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		next := graph[cur]
+		slices.Sort(next)
+		for _, id := range next {
+			if _, seen := first[id]; seen || id != dest && !o.net.Trusted(node.ID, id) {
+				continue
+			}
+			first[id] = first[cur]
+			if cur == node.ID {
+				first[id] = id
+			}
+			if id == dest {
+				return o.net.GetNodes()[first[id]], false
+			}
+			queue = append(queue, id)
+		}
+	}
+	return nil, false
+}
+
+// LinkFailed удаляет соседа до его следующего HELLO
+func (o *OLSRRouter) LinkFailed(node *models.DroneNode, next int, packet *models.Packet) {
+	if _, ok := o.st.Links[node.ID][next]; ok {
+		delete(o.st.Links[node.ID], next)
+		o.selectMPR(node.ID)
+	}
+}
+
+// NodeRemoved очищает таблицы узла; записи о нем у соседей устаревают сами
+func (o *OLSRRouter) NodeRemoved(node *models.DroneNode) {
+	delete(o.st.Links, node.ID)
+	delete(o.st.Topology, node.ID)
+	delete(o.st.MPR, node.ID)
+}
+
+func (o *OLSRRouter) Snapshot() ProtocolState {
+	return ProtocolState{OLSR: o.st}
+}
+
+func (o *OLSRRouter) Restore(st ProtocolState) {
+	o.st = st.OLSR
+	o.st.init()
+}
@@ -0,0 +1,255 @@
+// Файл: routing/protocol.go
+package routing
+
+import (
+	"drone_trust_sim/config"
+	"drone_trust_sim/models"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+// Network - то, что протоколу маршрутизации нужно от симулятора.
+// Интерфейс разрывает циклический импорт, как consensus.SimulatorState.
+type Network interface {
+	GetConfig() *config.SimulatorConfig
+	GetNodes() []*models.DroneNode
+	GetRand() *rand.Rand
+	GetSimulationTime() float64
+	GetNodeClusterHead(nodeID int) *models.DroneNode
+	// Neighbors - работающие соседи узла по порогу качества канала, в порядке ID
+	Neighbors(node *models.DroneNode) []*models.DroneNode
+	IsNeighbor(a, b *models.DroneNode) bool
+	// Trusted сообщает, можно ли observer вести трафик через target
+	// (всегда true при выключенном RoutingTrustFilter)
+	Trusted(observerID, targetID int) bool
+	// SendControl передает служебное сообщение соседу to или, при to = -1, всем соседям.
+	// Передача идет через MAC и расходует энергию отправителя и получателей.
+	SendControl(from *models.DroneNode, to int, msg *models.RoutingMessage)
+	// ScheduleTimer планирует вызов Protocol.Timer для узла через delay секунд
+	ScheduleTimer(nodeID int, delay float64, timer Timer)
+	// Forward продолжает пересылку пакета, который протокол задерживал до появления маршрута
+	Forward(node *models.DroneNode, packet *models.Packet)
+	// DropPacket фиксирует потерю задержанного протоколом пакета
+	DropPacket(node *models.DroneNode, packet *models.Packet, reason models.InteractionResult)
+}
+
+// Protocol - протокол маршрутизации пакетов данных. Симулятор вызывает Route на каждом хопе;
+// служебные сообщения протокол рассылает через Network.SendControl и получает в HandleControl.
+// Все методы вызываются только из цикла событий.
+type Protocol interface {
+	// Start запускает периодические процессы протокола; вызывается один раз в начале прогона
+	Start()
+	// Route выбирает следующий хоп пакета на узле node. held = true - протокол оставил
+	// пакет у себя (ищет маршрут) и сам вернет его через Forward или DropPacket;
+	// next = nil при held = false - маршрута нет, пакет теряется.
+	Route(node *models.DroneNode, packet *models.Packet) (next *models.DroneNode, held bool)
+	// HandleControl обрабатывает служебное сообщение, полученное узлом от соседа from
+	HandleControl(node *models.DroneNode, from int, msg *models.RoutingMessage)
+	// LinkFailed сообщает, что кадр от node к соседу next потерян на линии
+	LinkFailed(node *models.DroneNode, next int, packet *models.Packet)
+	// Timer - срабатывание таймера, запланированного через Network.ScheduleTimer
+	Timer(node *models.DroneNode, timer Timer)
+	// NodeJoined и NodeRemoved сообщают о входе (возвращении) и выбытии узла посреди прогона
+	NodeJoined(node *models.DroneNode)
+	NodeRemoved(node *models.DroneNode)
+	Snapshot() ProtocolState
+	Restore(st ProtocolState)
+}
+
+// Timer - таймер протокола на узле. Target - адресат поиска маршрута для TimerDiscovery.
+type Timer struct {
+	Kind   int
+	Target int
+}
+
+// Виды таймеров
+const (
+	TimerHello     = iota // Рассылка HELLO или маяка положения
+	TimerTC               // Рассылка TC (OLSR)
+	TimerDiscovery        // Истекло ожидание ответа на RREQ
+)
+
+// Типы служебных сообщений (RoutingMessage.Type)
+const (
+	MsgRREQ  = "RREQ"
+	MsgRREP  = "RREP"
+	MsgRERR  = "RERR"
+	MsgHello = "HELLO"
+	MsgTC    = "TC"
+)
+
+// ProtocolState - сериализуемое состояние протокола (для контрольных точек).
+// Заполнено только поле выбранного протокола; снимок ссылается на рабочие таблицы
+// и должен быть сериализован сразу.
+type ProtocolState struct {
+	AODV *AODVState
+	DSR  *DSRState
+	OLSR *OLSRState
+	GPSR *GPSRState
+}
+
+// Названия протоколов в SimulatorConfig.RoutingProtocol
+const (
+	ClusterGreedy = "ClusterGreedy" // Исходная жадная маршрутизация через главы кластеров, без служебного трафика
+	AODV          = "AODV"
+	DSR           = "DSR"
+	OLSR          = "OLSR"
+	GPSR          = "GPSR"
+)
+
+// Known сообщает, существует ли протокол с таким названием
+func Known(name string) bool {
+	switch name {
+	case ClusterGreedy, AODV, DSR, OLSR, GPSR:
+		return true
+	}
+	return false
+}
+
+// New создает протокол маршрутизации, выбранный в конфигурации
+func New(cfg *config.SimulatorConfig, net Network) (Protocol, error) {
+	switch cfg.RoutingProtocol {
+	case ClusterGreedy:
+		return &Greedy{net: net}, nil
+	case AODV:
+		return newAODV(net), nil
+	case DSR:
+		return newDSR(net), nil
+	case OLSR:
+		return newOLSR(net), nil
+	case GPSR:
+		return newGPSR(net), nil
+	}
+	return nil, fmt.Errorf("неизвестный протокол маршрутизации: %q", cfg.RoutingProtocol)
+}
+
+// pair - ключ таблиц «узел -> адресат»
+type pair struct {
+	Node, Dest int
+}
+
+// requestKey - ключ кэша уже обработанных узлом RREQ
+type requestKey struct {
+	Node, Origin, ID int
+}
+
+// discovery - поиск маршрута, ожидающий ответа
+type discovery struct {
+	Attempts int
+	Deadline float64 // Момент срабатывания актуального таймера; более ранние таймеры устарели
+}
+
+// buffer - пакеты, ожидающие маршрута, и ход их поиска (общая часть AODV и DSR)
+type buffer struct {
+	Pending   map[pair][]*models.Packet
+	Searching map[pair]discovery
+}
+
+// init создает недостающие таблицы (gob не передает пустые map)
+func (b *buffer) init() {
+	if b.Pending == nil {
+		b.Pending = make(map[pair][]*models.Packet)
+	}
+	if b.Searching == nil {
+		b.Searching = make(map[pair]discovery)
+	}
+}
+
+// hold ставит пакет в ожидание маршрута; true - поиск для этого адресата еще не идет
+func (b *buffer) hold(node *models.DroneNode, packet *models.Packet) bool {
+	key := pair{node.ID, packet.DestinationID}
+	b.Pending[key] = append(b.Pending[key], packet)
+	_, searching := b.Searching[key]
+	return !searching
+}
+
+// search фиксирует очередную попытку поиска и планирует ее таймер
+// (ожидание DiscoveryTimeout удваивается с каждой попыткой)
+func (b *buffer) search(net Network, nodeID, dest int) {
+	key := pair{nodeID, dest}
+	d := b.Searching[key]
+	d.Attempts++
+	delay := net.GetConfig().DiscoveryTimeout * float64(int(1)<<(d.Attempts-1))
+	d.Deadline = net.GetSimulationTime() + delay
+	b.Searching[key] = d
+	net.ScheduleTimer(nodeID, delay, Timer{Kind: TimerDiscovery, Target: dest})
+}
+
+// expired обрабатывает таймер поиска: true - нужен повтор RREQ. После исчерпания
+// DiscoveryRetries ожидающие пакеты теряются с причиной Failure_NoRoute.
+func (b *buffer) expired(net Network, node *models.DroneNode, dest int) bool {
+	key := pair{node.ID, dest}
+	d, ok := b.Searching[key]
+	if !ok || net.GetSimulationTime() < d.Deadline {
+		return false // Маршрут найден или таймер относится к прежней попытке
+	}
+	if d.Attempts <= net.GetConfig().DiscoveryRetries {
+		return true
+	}
+	b.fail(net, node, key, models.Failure_NoRoute)
+	return false
+}
+
+// release возвращает ожидавшие пакеты в пересылку после нахождения маршрута
+func (b *buffer) release(net Network, node *models.DroneNode, dest int, prepare func(*models.Packet)) {
+	key := pair{node.ID, dest}
+	packets := b.Pending[key]
+	delete(b.Pending, key)
+	delete(b.Searching, key)
+	for _, p := range packets {
+		if prepare != nil {
+			prepare(p)
+		}
+		net.Forward(node, p)
+	}
+}
+
+// drop сбрасывает все пакеты, ожидающие маршрута на выбывшем узле
+func (b *buffer) drop(net Network, node *models.DroneNode) {
+	for key := range b.Searching {
+		if key.Node == node.ID {
+			delete(b.Searching, key)
+		}
+	}
+	for _, key := range sortedPairs(b.Pending) {
+		if key.Node == node.ID {
+			b.fail(net, node, key, models.Failure_NodeDead)
+		}
+	}
+}
+
+func (b *buffer) fail(net Network, node *models.DroneNode, key pair, reason models.InteractionResult) {
+	packets := b.Pending[key]
+	delete(b.Pending, key)
+	delete(b.Searching, key)
+	for _, p := range packets {
+		net.DropPacket(node, p, reason)
+	}
+}
+
+// sortedPairs возвращает ключи таблицы в детерминированном порядке
+func sortedPairs[V any](m map[pair]V) []pair {
+	keys := make([]pair, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b pair) int {
+		if a.Node != b.Node {
+			return a.Node - b.Node
+		}
+		return a.Dest - b.Dest
+	})
+	return keys
+}
+
+// trustedFrom - проверка доверия к отправителю служебного сообщения: сообщения
+// от узлов, через которые нельзя вести трафик, игнорируются
+func trustedFrom(net Network, node *models.DroneNode, from int) bool {
+	return net.Trusted(node.ID, from)
+}
+
+// jitter - случайная задержка первого периодического сообщения узла, чтобы узлы не вещали разом
+func jitter(net Network, period float64) float64 {
+	return net.GetRand().Float64() * period
+}
@@ -0,0 +1,196 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"drone_trust_sim/trace"
+	"maps"
+	"slices"
+)
+
+// ackState - сквозные подтверждения: неподтвержденные пакеты источников, уже доставленные
+// пакеты (чтобы повтор не засчитывался получателю дважды) и пакеты, потеря которых уже
+// учтена в Drops (чтобы пакет считался потерянным один раз, сколько бы копий ни пропало)
+type ackState struct {
+	Pending   map[int]*pendingAck // ID пакета данных -> ожидание его ACK у источника
+	Delivered map[int]bool
+	Dropped   map[int]bool
+}
+
+// pendingAck - пакет, для которого источник ждет подтверждения
+type pendingAck struct {
+	Packet  models.Packet            // Копия пакета в момент создания: из нее собираются повторы
+	Retries int                      // Уже выполненные повторы
+	Lost    []int                    // Маршрут последней потерянной копии; nil - потеря источнику не видна
+	Reason  models.InteractionResult // Причина потери текущей копии; InteractionSuccess - копия не потеряна
+}
+
+func newAckState() ackState {
+	return ackState{Pending: make(map[int]*pendingAck), Delivered: make(map[int]bool), Dropped: make(map[int]bool)}
+}
+
+// init создает недостающие таблицы (gob не передает пустые map)
+func (st *ackState) init() {
+	if st.Pending == nil {
+		st.Pending = make(map[int]*pendingAck)
+	}
+	if st.Delivered == nil {
+		st.Delivered = make(map[int]bool)
+	}
+	if st.Dropped == nil {
+		st.Dropped = make(map[int]bool)
+	}
+}
+
+// awaitAck запоминает новый пакет данных и запускает таймер ожидания подтверждения
+func (s *Simulator) awaitAck(packet *models.Packet) {
+	if !s.Cfg.EndToEndAck {
+		return
+	}
+	s.ack.Pending[packet.ID] = &pendingAck{Packet: *packet}
+	s.scheduleAckTimeout(packet)
+}
+
+func (s *Simulator) scheduleAckTimeout(packet *models.Packet) {
+	s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.AckTimeout, Type: EventAckTimeout, NodeID: packet.SourceID, Data: packet.ID})
+}
+
+// deliverData засчитывает пакет данных получателю и отвечает источнику подтверждением.
+// Повторно доставленная копия не учитывается, но подтверждается снова: прежний ACK мог потеряться.
+func (s *Simulator) deliverData(node *models.DroneNode, packet *models.Packet) {
+	if !s.ack.Delivered[packet.ID] {
+		if s.Cfg.EndToEndAck {
+			s.ack.Delivered[packet.ID] = true
+		}
+		s.tracePacket(trace.TypePacketDeliver, node.ID, packet.SourceID, packet)
+		s.Metrics.RecordPacketDelivered(s.CurrentTime-packet.CreationTime, packet.Size)
+		s.Nodes[packet.SourceID].Mutex.Lock()
+		s.Nodes[packet.SourceID].PacketsDelivered++
+		s.Nodes[packet.SourceID].Mutex.Unlock()
+		for _, h := range s.hooks {
+			h.OnPacketDelivered(s.CurrentTime, node, packet)
+		}
+	}
+	if !s.Cfg.EndToEndAck {
+		return
+	}
+	ack := &models.Packet{
+		ID:            s.GetNextPacketID(),
+		SourceID:      node.ID,
+		DestinationID: packet.SourceID,
+		CreationTime:  s.CurrentTime,
+		IsAck:         true,
+		Size:          s.Cfg.AckPacketSize,
+		Path:          []int{node.ID},
+		AckedID:       packet.ID,
+		AckedPath:     slices.Clone(packet.Path),
+	}
+	s.Metrics.RecordAckSent()
+	s.routePacket(node, ack)
+}
+
+// receiveAck закрывает ожидание у источника. Подтверждение - свидетельство в пользу
+// каждого транзитного узла, через который прошел пакет данных.
+func (s *Simulator) receiveAck(node *models.DroneNode, ack *models.Packet) {
+	if _, ok := s.ack.Pending[ack.AckedID]; !ok {
+		return // Подтверждение более ранней копии уже получено
+	}
+	delete(s.ack.Pending, ack.AckedID)
+	s.emit(&trace.Record{Type: trace.TypePacketAck, Node: node.ID, Peer: ack.SourceID, Packet: ack.AckedID})
+	for _, hop := range transitNodes(ack.AckedPath, node.ID, ack.SourceID) {
+		s.recordInteraction(node.ID, hop, models.InteractionSuccess)
+	}
+}
+
+// ackLost запоминает, где потерялась копия пакета данных или его подтверждение.
+// Источник сам этого не видит, но по тайм-ауту винит именно этот маршрут.
+func (s *Simulator) ackLost(packet *models.Packet) {
+	id := packet.ID
+	if packet.IsAck {
+		id = packet.AckedID
+	}
+	if p, ok := s.ack.Pending[id]; ok {
+		p.Lost = slices.Clone(packet.Path)
+	}
+}
+
+// recordDataDrop учитывает потерю пакета данных в Drops. При сквозных подтверждениях
+// в Drops попадает исход пакета, а не каждой копии: потеря копии, которую источник еще
+// повторит, только запоминается, а учитывается причина потери последней копии.
+func (s *Simulator) recordDataDrop(packetID int, reason models.InteractionResult) {
+	if !s.Cfg.EndToEndAck {
+		s.Metrics.RecordDrop(reason)
+		return
+	}
+	if p, ok := s.ack.Pending[packetID]; ok {
+		p.Reason = reason
+		return
+	}
+	s.finalDrop(packetID, reason) // Источник уже не ждет ACK: повторов не будет
+}
+
+// finalDrop учитывает потерю пакета, если он не доставлен и еще не учтен
+func (s *Simulator) finalDrop(packetID int, reason models.InteractionResult) {
+	if s.ack.Delivered[packetID] || s.ack.Dropped[packetID] {
+		return
+	}
+	s.ack.Dropped[packetID] = true
+	s.Metrics.RecordDrop(reason)
+}
+
+// handleAckTimeout: подтверждение не пришло. Источник записывает Failure_NoAck против
+// транзитных узлов маршрута потерянной копии и повторяет пакет, пока не исчерпает
+// MaxRetransmissions.
+func (s *Simulator) handleAckTimeout(packetID int) {
+	p, ok := s.ack.Pending[packetID]
+	if !ok {
+		return
+	}
+	source := s.Nodes[p.Packet.SourceID]
+	for _, hop := range transitNodes(p.Lost, source.ID, p.Packet.DestinationID) {
+		s.recordInteraction(source.ID, hop, models.Failure_NoAck)
+	}
+	p.Lost = nil
+	if p.Retries >= s.Cfg.MaxRetransmissions {
+		delete(s.ack.Pending, packetID)
+		if p.Reason != models.InteractionSuccess {
+			s.finalDrop(packetID, p.Reason)
+		}
+		return
+	}
+	p.Reason = models.InteractionSuccess
+	p.Retries++
+	s.Metrics.RecordRetransmission()
+	retry := p.Packet
+	retry.Path = []int{source.ID}
+	s.tracePacket(trace.TypePacketRetransmit, source.ID, retry.DestinationID, &retry)
+	for _, h := range s.hooks {
+		h.OnPacketSent(s.CurrentTime, &retry)
+	}
+	s.routePacket(source, &retry)
+	s.scheduleAckTimeout(&retry)
+}
+
+// dropPendingAcks снимает ожидания выбывшего источника. Уже потерянные копии становятся
+// окончательными потерями; копии в пути будут учтены при доставке или потере.
+func (s *Simulator) dropPendingAcks(nodeID int) {
+	maps.DeleteFunc(s.ack.Pending, func(id int, p *pendingAck) bool {
+		if p.Packet.SourceID != nodeID {
+			return false
+		}
+		if p.Reason != models.InteractionSuccess {
+			s.finalDrop(id, p.Reason)
+		}
+		return true
+	})
+}
+
+// transitNodes возвращает узлы маршрута, кроме источника и адресата, без повторов
+func transitNodes(path []int, source, destination int) []int {
+	var out []int
+	for _, id := range path {
+		if id != source && id != destination && !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
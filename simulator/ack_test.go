@@ -0,0 +1,105 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"testing"
+)
+
+// newAckSimulator запускает короткий прогон со сквозными подтверждениями до момента t,
+// чтобы кластеры и маршрутизация уже работали
+func newAckSimulator(t *testing.T, retransmissions int) *Simulator {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.EndToEndAck = true
+	cfg.MaxRetransmissions = retransmissions
+	s := newTestSimulator(t, cfg)
+	s.RunUntil(5)
+	return s
+}
+
+func dataPacket(s *Simulator, source, destination int) *models.Packet {
+	return &models.Packet{ID: s.GetNextPacketID(), SourceID: source, DestinationID: destination,
+		CreationTime: s.CurrentTime, Size: s.Cfg.DataPacketSize, Path: []int{source}}
+}
+
+// Повторно доставленная копия не засчитывается, но подтверждается снова
+func TestAckDeduplicatesDelivery(t *testing.T) {
+	s := newAckSimulator(t, 3)
+	packet := dataPacket(s, 0, 1)
+	s.awaitAck(packet)
+	delivered, acks := s.Metrics.PacketsDelivered, s.Metrics.AcksSent
+
+	s.deliverData(s.Nodes[1], packet)
+	s.deliverData(s.Nodes[1], packet)
+	if got := s.Metrics.PacketsDelivered - delivered; got != 1 {
+		t.Errorf("засчитано доставок: %d, ожидалась 1", got)
+	}
+	if got := s.Metrics.AcksSent - acks; got != 2 {
+		t.Errorf("отправлено ACK: %d, ожидалось 2", got)
+	}
+}
+
+// Без ACK источник повторяет пакет, пока не исчерпает MaxRetransmissions
+func TestAckRetransmitsUntilLimit(t *testing.T) {
+	s := newAckSimulator(t, 2)
+	packet := dataPacket(s, 0, 1)
+	s.awaitAck(packet)
+	retransmissions := s.Metrics.Retransmissions
+
+	for range 3 {
+		s.handleAckTimeout(packet.ID)
+	}
+	if got := s.Metrics.Retransmissions - retransmissions; got != 2 {
+		t.Errorf("повторов: %d, ожидалось 2", got)
+	}
+	if _, ok := s.ack.Pending[packet.ID]; ok {
+		t.Error("источник ждет ACK после исчерпания повторов")
+	}
+}
+
+// Потерянный пакет попадает в разбивку потерь один раз, с причиной потери последней
+// копии, сколько бы копий ни пропало
+func TestAckCountsUndeliveredPacketOnce(t *testing.T) {
+	s := newAckSimulator(t, 1)
+	packet := dataPacket(s, 0, 1)
+	s.awaitAck(packet)
+	before := dropCount(s)
+
+	s.dropPacket(0, -1, packet, models.Failure_OutOfRange)
+	s.handleAckTimeout(packet.ID) // Повтор
+	s.dropPacket(0, -1, packet, models.Failure_Collision)
+	if got := dropCount(s) - before; got != 0 {
+		t.Fatalf("потери копий учтены до окончательного исхода: %d", got)
+	}
+	s.handleAckTimeout(packet.ID) // Источник больше не ждет
+	s.dropPacket(0, -1, packet, models.Failure_NoRoute)
+	if got := dropCount(s) - before; got != 1 {
+		t.Errorf("учтено потерь: %d, ожидалась 1", got)
+	}
+	if s.Metrics.Drops[models.Failure_Collision] == 0 {
+		t.Error("причина окончательной потери - не потеря последней копии")
+	}
+}
+
+// Пакет, доставленный хотя бы одной копией, потерянным не считается
+func TestAckDeliveredPacketIsNotDropped(t *testing.T) {
+	s := newAckSimulator(t, 0)
+	packet := dataPacket(s, 0, 1)
+	s.awaitAck(packet)
+	before := dropCount(s)
+
+	s.deliverData(s.Nodes[1], packet)
+	s.handleAckTimeout(packet.ID) // ACK потерялся
+	s.dropPacket(0, -1, packet, models.Failure_OutOfRange)
+	if got := dropCount(s) - before; got != 0 {
+		t.Errorf("доставленный пакет учтен как потерянный: %d", got)
+	}
+}
+
+func dropCount(s *Simulator) int {
+	n := 0
+	for _, v := range s.Metrics.Drops {
+		n += v
+	}
+	return n
+}
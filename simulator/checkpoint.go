@@ -0,0 +1,266 @@
+package simulator
+
+import (
+	"container/heap"
+	"drone_trust_sim/config"
+	"drone_trust_sim/metrics"
+	"drone_trust_sim/mobility"
+	"drone_trust_sim/models"
+	"drone_trust_sim/routing"
+	"drone_trust_sim/traffic"
+	"drone_trust_sim/trust"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const checkpointVersion = 1
+
+func init() {
+	// Конкретные типы, которые встречаются в Event.Data
+	gob.Register(PacketArrivalData{})
+	gob.Register(ConsensusEndData{})
+	gob.Register(routing.Timer{})
+}
+
+// checkpoint - полный снимок симуляции. Все поля экспортируемые, чтобы их видел gob.
+type checkpoint struct {
+	Version       int
+	Cfg           config.SimulatorConfig
+	Seed          int64
+	RNG           []byte
+	CurrentTime   float64
+	PacketCounter int
+	EventSeq      uint64
+	Started       bool
+	Nodes         []nodeState
+	Trust         trust.State
+	Clusters      routing.State
+	Routing       routing.ProtocolState
+	Metrics       metrics.State
+	Mobility      mobility.State
+	Traffic       traffic.State
+	MAC           macState
+	ACK           ackState
+	Events        []eventState
+}
+
+// nodeState - копия DroneNode без мьютекса (sync.RWMutex gob не сериализует)
+type nodeState struct {
+	ID                  int
+	IsMalicious         bool
+	Location            models.Point
+	ComputationalPower  float64
+	IsClusterHead       bool
+	ClusterID           int
+	Energy              float64
+	Status              models.NodeStatus
+	IsGroundStation     bool
+	PacketsSent         int
+	PacketsDelivered    int
+	PacketsForwarded    int
+	PacketsDroppedByMe  int
+	ConsensusRounds     int
+	ValidBlocksProposed int
+}
+
+type eventState struct {
+	Time   float64
+	Type   EventType
+	NodeID int
+	Data   interface{}
+	Seq    uint64
+}
+
+// SaveCheckpoint сохраняет текущее состояние симуляции в файл.
+// Вызывать между событиями: из обработчика события или до/после Run.
+func (s *Simulator) SaveCheckpoint(path string) error {
+	rngState, err := s.rngSrc.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить состояние ГСЧ: %w", err)
+	}
+
+	cp := checkpoint{
+		Version:       checkpointVersion,
+		Cfg:           *s.Cfg,
+		Seed:          s.Seed,
+		RNG:           rngState,
+		CurrentTime:   s.CurrentTime,
+		PacketCounter: s.PacketCounter,
+		EventSeq:      s.eventSeq,
+		Started:       s.started,
+		Trust:         s.TrustManager.Snapshot(),
+		Clusters:      s.ClusterManager.Snapshot(),
+		Routing:       s.Routing.Snapshot(),
+		Metrics:       s.Metrics.Snapshot(),
+		Mobility:      s.Mobility.Snapshot(),
+		Traffic:       s.Traffic.Snapshot(),
+		MAC:           s.mac,
+		ACK:           s.ack,
+	}
+	for _, n := range s.Nodes {
+		n.Mutex.RLock()
+		cp.Nodes = append(cp.Nodes, nodeState{
+			ID:                  n.ID,
+			IsMalicious:         n.IsMalicious,
+			Location:            n.Location,
+			ComputationalPower:  n.ComputationalPower,
+			IsClusterHead:       n.IsClusterHead,
+			ClusterID:           n.ClusterID,
+			Energy:              n.Energy,
+			Status:              n.Status,
+			IsGroundStation:     n.IsGroundStation,
+			PacketsSent:         n.PacketsSent,
+			PacketsDelivered:    n.PacketsDelivered,
+			PacketsForwarded:    n.PacketsForwarded,
+			PacketsDroppedByMe:  n.PacketsDroppedByMe,
+			ConsensusRounds:     n.ConsensusRounds,
+			ValidBlocksProposed: n.ValidBlocksProposed,
+		})
+		n.Mutex.RUnlock()
+	}
+	s.EventQueueMux.Lock()
+	for _, evt := range s.EventQueue {
+		cp.Events = append(cp.Events, eventState{Time: evt.Time, Type: evt.Type, NodeID: evt.NodeID, Data: evt.Data, Seq: evt.seq})
+	}
+	s.EventQueueMux.Unlock()
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("не удалось создать директорию: %w", err)
+		}
+	}
+	// Пишем во временный файл и переименовываем, чтобы сбой не оставил битую точку
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(&cp); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("ошибка сериализации: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// LoadCheckpoint восстанавливает симуляцию из файла. Перед Run можно изменить
+// s.Cfg (например, алгоритм) или узлы, чтобы ответвить эксперимент от общего состояния.
+func LoadCheckpoint(path string) (*Simulator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cp checkpoint
+	if err := gob.NewDecoder(f).Decode(&cp); err != nil {
+		return nil, fmt.Errorf("ошибка чтения контрольной точки: %w", err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("неподдерживаемая версия контрольной точки: %d", cp.Version)
+	}
+
+	cfg := cp.Cfg
+	rng, src := newRand(cp.Seed)
+	if err := src.UnmarshalBinary(cp.RNG); err != nil {
+		return nil, fmt.Errorf("не удалось восстановить состояние ГСЧ: %w", err)
+	}
+	s := &Simulator{
+		Cfg:           &cfg,
+		Metrics:       metrics.NewCollector(),
+		EventQueue:    make(PriorityQueue, 0, len(cp.Events)),
+		CurrentTime:   cp.CurrentTime,
+		PacketCounter: cp.PacketCounter,
+		Seed:          cp.Seed,
+		Rng:           rng,
+		rngSrc:        src,
+		eventSeq:      cp.EventSeq,
+		started:       cp.Started,
+	}
+
+	s.Nodes = make([]*models.DroneNode, len(cp.Nodes))
+	for i, ns := range cp.Nodes {
+		if ns.ID != i {
+			return nil, fmt.Errorf("нарушен порядок узлов: позиция %d, ID %d", i, ns.ID)
+		}
+		s.Nodes[i] = &models.DroneNode{
+			ID:                  ns.ID,
+			IsMalicious:         ns.IsMalicious,
+			Location:            ns.Location,
+			ComputationalPower:  ns.ComputationalPower,
+			IsClusterHead:       ns.IsClusterHead,
+			ClusterID:           ns.ClusterID,
+			Energy:              ns.Energy,
+			Status:              ns.Status,
+			IsGroundStation:     ns.IsGroundStation,
+			PacketsSent:         ns.PacketsSent,
+			PacketsDelivered:    ns.PacketsDelivered,
+			PacketsForwarded:    ns.PacketsForwarded,
+			PacketsDroppedByMe:  ns.PacketsDroppedByMe,
+			ConsensusRounds:     ns.ConsensusRounds,
+			ValidBlocksProposed: ns.ValidBlocksProposed,
+		}
+	}
+
+	if err := s.attachManagers(); err != nil {
+		return nil, err
+	}
+	s.Mobility.Restore(cp.Mobility)
+	s.Traffic.Restore(cp.Traffic)
+	s.indexNodes()
+	if err := s.TrustManager.Restore(cp.Trust); err != nil {
+		return nil, err
+	}
+	if err := s.ClusterManager.Restore(cp.Clusters); err != nil {
+		return nil, err
+	}
+	s.Routing.Restore(cp.Routing)
+	s.Metrics.Restore(cp.Metrics)
+	if len(cp.MAC.Nodes) != len(s.Nodes) {
+		return nil, fmt.Errorf("состояние MAC на %d узлов, в симуляции %d", len(cp.MAC.Nodes), len(s.Nodes))
+	}
+	s.mac = cp.MAC
+	if s.mac.Rounds == nil {
+		s.mac.Rounds = make(map[int]*consensusRound)
+	}
+	s.ack = cp.ACK
+	s.ack.init()
+
+	for _, es := range cp.Events {
+		heap.Push(&s.EventQueue, &Event{Time: es.Time, Type: es.Type, NodeID: es.NodeID, Data: es.Data, seq: es.Seq})
+	}
+	return s, nil
+}
+
+// scheduleCheckpoints планирует сохранение контрольных точек по конфигурации
+func (s *Simulator) scheduleCheckpoints() {
+	if s.Cfg.CheckpointInterval > 0 {
+		s.scheduleEvent(&Event{Time: s.Cfg.CheckpointInterval, Type: EventCheckpoint, Data: true})
+	}
+	for _, t := range s.Cfg.CheckpointTimes {
+		s.scheduleEvent(&Event{Time: t, Type: EventCheckpoint, Data: false})
+	}
+}
+
+func (s *Simulator) checkpointPath() string {
+	return filepath.Join(s.Cfg.CheckpointDir, fmt.Sprintf("checkpoint_t%.2f.gob", s.CurrentTime))
+}
+
+// SetMaliciousRatio переназначает злонамеренные узлы (первые ratio*N, как при создании).
+// Наземные станции злонамеренными не бывают.
+// Используется для внедрения атаки в симуляцию, возобновленную из контрольной точки.
+func (s *Simulator) SetMaliciousRatio(ratio float64) {
+	s.Cfg.MaliciousRatio = ratio
+	maliciousCount := int(float64(len(s.Nodes)) * ratio)
+	for i, n := range s.Nodes {
+		n.Mutex.Lock()
+		n.IsMalicious = i < maliciousCount && !n.IsGroundStation
+		n.Mutex.Unlock()
+	}
+}
@@ -0,0 +1,153 @@
+package simulator
+
+import (
+	"context"
+	"drone_trust_sim/metrics"
+	"drone_trust_sim/models"
+	"errors"
+)
+
+// Пошаговое управление прогоном для интерактивной работы, отладки и внешних циклов
+// управления: мир можно остановить, изучить доверие и кластеры и продолжить.
+// Все методы, кроме Pause, вызываются из той же горутины, что выполняет прогон.
+
+// ErrPaused возвращает Resume, если прогон остановлен вызовом Pause
+var ErrPaused = errors.New("симуляция приостановлена")
+
+// Step обрабатывает одно событие. Возвращает false, если до SimulationTime событий не осталось.
+func (s *Simulator) Step() bool {
+	s.begin()
+	evt := s.popEvent(s.Cfg.SimulationTime)
+	if evt == nil {
+		return false
+	}
+	s.CurrentTime = evt.Time
+	s.handleEvent(evt)
+	return true
+}
+
+// RunUntil обрабатывает все события до момента t включительно и переводит часы на t
+// (не дальше SimulationTime). Возвращает false, если прогон завершен.
+func (s *Simulator) RunUntil(t float64) bool {
+	s.advance(t, nil)
+	s.CurrentTime = max(s.CurrentTime, min(t, s.Cfg.SimulationTime))
+	return !s.Done()
+}
+
+// Pause просит остановить прогон, выполняемый Resume, перед следующим событием.
+// Безопасно вызывается из любой горутины. Запрос, сделанный вне Resume, остановит следующий вызов.
+func (s *Simulator) Pause() {
+	s.pauseRequested.Store(true)
+}
+
+// Resume выполняет прогон до SimulationTime и возвращает итоговые метрики. Если прогон
+// остановлен вызовом Pause или отменой ctx, возвращается ErrPaused или ctx.Err();
+// повторный вызов Resume продолжает с того же места.
+func (s *Simulator) Resume(ctx context.Context) (*metrics.FinalMetrics, error) {
+	err := s.advance(s.Cfg.SimulationTime, func() error {
+		if s.pauseRequested.Swap(false) {
+			return ErrPaused
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Metrics.CalculateFinalMetrics(s), nil
+}
+
+// Done сообщает, что до SimulationTime событий не осталось
+func (s *Simulator) Done() bool {
+	s.EventQueueMux.Lock()
+	defer s.EventQueueMux.Unlock()
+	return s.started && (s.EventQueue.Len() == 0 || s.EventQueue[0].Time > s.Cfg.SimulationTime)
+}
+
+// Now возвращает текущее модельное время
+func (s *Simulator) Now() float64 {
+	return s.CurrentTime
+}
+
+// CurrentMetrics рассчитывает метрики на текущий момент, не прерывая прогон
+func (s *Simulator) CurrentMetrics() *metrics.FinalMetrics {
+	return s.Metrics.CalculateFinalMetrics(s)
+}
+
+// NodeView - копия состояния узла на момент запроса
+type NodeView struct {
+	ID              int
+	Location        models.Point
+	Energy          float64
+	Status          models.NodeStatus
+	IsMalicious     bool
+	IsGroundStation bool
+	IsClusterHead   bool
+	ClusterID       int
+
+	PacketsSent        int
+	PacketsDelivered   int
+	PacketsForwarded   int
+	PacketsDroppedByMe int
+}
+
+// Node возвращает состояние узла с указанным ID
+func (s *Simulator) Node(id int) NodeView {
+	n := s.Nodes[id]
+	n.Mutex.RLock()
+	defer n.Mutex.RUnlock()
+	return NodeView{
+		ID:                 n.ID,
+		Location:           n.Location,
+		Energy:             n.Energy,
+		Status:             n.Status,
+		IsMalicious:        n.IsMalicious,
+		IsGroundStation:    n.IsGroundStation,
+		IsClusterHead:      n.IsClusterHead,
+		ClusterID:          n.ClusterID,
+		PacketsSent:        n.PacketsSent,
+		PacketsDelivered:   n.PacketsDelivered,
+		PacketsForwarded:   n.PacketsForwarded,
+		PacketsDroppedByMe: n.PacketsDroppedByMe,
+	}
+}
+
+// NodeViews возвращает состояния всех узлов, включая выбывшие, в порядке ID
+func (s *Simulator) NodeViews() []NodeView {
+	views := make([]NodeView, len(s.Nodes))
+	for i := range s.Nodes {
+		views[i] = s.Node(i)
+	}
+	return views
+}
+
+// Trust возвращает доверие observerID к targetID. Для наблюдателя, разрядившегося
+// или отказавшего навсегда, возвращается 0: его мнения о других не хранятся.
+// Мнения ушедшего на время узла сохраняются до его возвращения.
+func (s *Simulator) Trust(observerID, targetID int) float64 {
+	return s.TrustManager.GetTrust(observerID, targetID)
+}
+
+// Clusters возвращает состав кластеров: ID кластера -> ID участников
+func (s *Simulator) Clusters() map[int][]int {
+	clusters := s.ClusterManager.GetClusters()
+	out := make(map[int][]int, len(clusters))
+	for clusterID, members := range clusters {
+		ids := make([]int, 0, len(members))
+		for _, m := range members {
+			ids = append(ids, m.ID)
+		}
+		out[clusterID] = ids
+	}
+	return out
+}
+
+// ClusterHeads возвращает CH кластеров: ID кластера -> ID узла. Кластеры без CH не входят.
+func (s *Simulator) ClusterHeads() map[int]int {
+	heads := make(map[int]int)
+	for clusterID := range s.ClusterManager.GetClusters() {
+		if ch := s.ClusterManager.GetClusterHead(clusterID); ch != nil {
+			heads[clusterID] = ch.ID
+		}
+	}
+	return heads
+}
@@ -0,0 +1,50 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+)
+
+// consumeEnergy списывает энергию узла и проверяет разряд батареи.
+// Возвращает false, если после списания узел мертв. Наземные станции питаются от сети.
+func (s *Simulator) consumeEnergy(node *models.DroneNode, amount float64) bool {
+	if node.IsGroundStation {
+		return node.Alive()
+	}
+	node.Mutex.Lock()
+	node.Energy -= amount
+	node.Mutex.Unlock()
+	return s.checkDepleted(node)
+}
+
+// checkDepleted выводит из сети узел с разряженной батареей. Возвращает false, если узел мертв.
+func (s *Simulator) checkDepleted(node *models.DroneNode) bool {
+	if !node.Alive() {
+		return false
+	}
+	if node.Energy > 0 {
+		return true
+	}
+	s.killNode(node)
+	return false
+}
+
+// killNode выводит из сети узел с разряженной батареей и фиксирует момент его гибели
+func (s *Simulator) killNode(node *models.DroneNode) {
+	s.removeNode(node, models.NodeDead)
+	s.Metrics.RecordNodeDeath(s.CurrentTime)
+}
+
+// flightEnergy - расход на полет за dt секунд с перемещением на distance
+func (s *Simulator) flightEnergy(dt, distance float64) float64 {
+	return s.Cfg.EnergyHover*dt + s.Cfg.EnergyMove*distance
+}
+
+// hasAlivePeer сообщает, есть ли в сети работающий узел, кроме nodeID
+func (s *Simulator) hasAlivePeer(nodeID int) bool {
+	for _, n := range s.Nodes {
+		if n.ID != nodeID && n.Alive() {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,16 @@ const (
 	EventCHReelection
 	EventConsensusStart
 	EventConsensusEnd
+	EventCheckpoint
+	EventMACAccess    // Окончание отсрочки: узел проверяет канал и начинает передачу
+	EventMACTxEnd     // Окончание передачи кадра
+	EventNodeJoin     // Вход нового дрона (NodeID = -1) или возвращение ушедшего
+	EventNodeLeave    // Уход дрона из роя с возможностью вернуться
+	EventNodeCrash    // Необратимый отказ дрона
+	EventRoutingTimer // Таймер протокола маршрутизации узла (Data - routing.Timer)
+	EventAckTimeout   // Истекло ожидание сквозного подтверждения (Data - ID пакета данных)
+	EventSnapshot     // Рисунок топологии роя в SVG
+	EventSample       // Отсчет временного ряда метрик
 )
 
 type Event struct {
@@ -21,6 +31,7 @@ type Event struct {
 	NodeID int
 	Data   interface{} // Дополнительные данные, например, сам пакет
 	index  int
+	seq    uint64 // Порядок постановки в очередь: разрешает события с одинаковым временем детерминированно
 }
 
 // PriorityQueue реализует heap.Interface
@@ -36,7 +47,10 @@ func (pq PriorityQueue) Less(i, j int) bool {
 	if pq[j] == nil {
 		log.Fatalf("FATAL: pq[j] is nil at index %d", j)
 	}
-	return pq[i].Time < pq[j].Time
+	if pq[i].Time != pq[j].Time {
+		return pq[i].Time < pq[j].Time
+	}
+	return pq[i].seq < pq[j].seq
 }
 
 func (pq PriorityQueue) Swap(i, j int) {
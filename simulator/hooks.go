@@ -0,0 +1,72 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"drone_trust_sim/trace"
+	"maps"
+	"slices"
+)
+
+// Hook - наблюдатель за прогоном для программ, встраивающих симулятор как библиотеку:
+// собственные метрики, визуализация, журналы. Методы вызываются синхронно из цикла
+// событий в момент события (t - модельное время); переданные узлы и пакеты принадлежат
+// симулятору, менять их нельзя. Чтобы реализовать только часть методов, достаточно
+// встроить BaseHook.
+type Hook interface {
+	// OnPacketSent - источник отправил пакет данных: новый или повтор по тайм-ауту ACK
+	OnPacketSent(t float64, packet *models.Packet)
+	// OnPacketForwarded - пакет данных или подтверждение успешно переданы по линии from -> to
+	OnPacketForwarded(t float64, from, to int, packet *models.Packet)
+	// OnPacketDelivered - пакет данных впервые доставлен адресату node
+	OnPacketDelivered(t float64, node *models.DroneNode, packet *models.Packet)
+	// OnPacketDropped - пакет данных или подтверждение потеряны на узле nodeID
+	OnPacketDropped(t float64, nodeID int, packet *models.Packet, reason models.InteractionResult)
+	// OnCHElected - итог выборов в кластере; head = nil, если CH не выбран
+	OnCHElected(t float64, clusterID int, head *models.DroneNode)
+	// OnTrustUpdated - наблюдение result изменило доверие observerID к targetID до value
+	OnTrustUpdated(t float64, observerID, targetID int, value float64, result models.InteractionResult)
+	// OnConsensusRound - раунд консенсуса в кластере завершен; block = nil, если раунд не удался
+	OnConsensusRound(t float64, clusterID int, block *models.Block)
+	// OnNodeDied - узел выбыл безвозвратно: разрядился (NodeDead) или отказал (NodeCrashed)
+	OnNodeDied(t float64, node *models.DroneNode)
+}
+
+// BaseHook - пустая реализация Hook для встраивания
+type BaseHook struct{}
+
+func (BaseHook) OnPacketSent(float64, *models.Packet)                                   {}
+func (BaseHook) OnPacketForwarded(float64, int, int, *models.Packet)                    {}
+func (BaseHook) OnPacketDelivered(float64, *models.DroneNode, *models.Packet)           {}
+func (BaseHook) OnPacketDropped(float64, int, *models.Packet, models.InteractionResult) {}
+func (BaseHook) OnCHElected(float64, int, *models.DroneNode)                            {}
+func (BaseHook) OnTrustUpdated(float64, int, int, float64, models.InteractionResult)    {}
+func (BaseHook) OnConsensusRound(float64, int, *models.Block)                           {}
+func (BaseHook) OnNodeDied(float64, *models.DroneNode)                                  {}
+
+// AddHook подключает наблюдателя; вызывается до Run. Наблюдатели вызываются в порядке
+// подключения. В контрольные точки они не попадают: после LoadCheckpoint их нужно подключить заново.
+func (s *Simulator) AddHook(h Hook) {
+	s.hooks = append(s.hooks, h)
+}
+
+// packetForwarded фиксирует успешную передачу пакета данных по линии в трассе и у наблюдателей
+func (s *Simulator) packetForwarded(sender, receiver *models.DroneNode, packet *models.Packet) {
+	s.tracePacket(trace.TypePacketForward, sender.ID, receiver.ID, packet)
+	for _, h := range s.hooks {
+		h.OnPacketForwarded(s.CurrentTime, sender.ID, receiver.ID, packet)
+	}
+}
+
+// notifyCHElected сообщает наблюдателям итоги выборов во всех кластерах в порядке ID
+func (s *Simulator) notifyCHElected() {
+	if len(s.hooks) == 0 {
+		return
+	}
+	clusters := s.ClusterManager.GetClusters()
+	for _, clusterID := range slices.Sorted(maps.Keys(clusters)) {
+		head := s.ClusterManager.GetClusterHead(clusterID)
+		for _, h := range s.hooks {
+			h.OnCHElected(s.CurrentTime, clusterID, head)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"testing"
+)
+
+// loopDrops пересылает пакет с узла sender и возвращает, сколько пакетов при этом
+// сброшено как петля
+func loopDrops(s *Simulator, sender int, packet *models.Packet) int {
+	before := s.Metrics.Drops[models.Failure_PacketLoop]
+	s.routePacket(s.Nodes[sender], packet)
+	return s.Metrics.Drops[models.Failure_PacketLoop] - before
+}
+
+func TestPacketLoopDetection(t *testing.T) {
+	s := newTestSimulator(t, testConfig(t))
+	s.RunUntil(5)
+	packet := func(hops int, path ...int) *models.Packet {
+		return &models.Packet{ID: s.GetNextPacketID(), SourceID: path[0], DestinationID: 20,
+			CreationTime: s.CurrentTime, Size: s.Cfg.DataPacketSize, Hops: hops, Path: path}
+	}
+
+	if n := loopDrops(s, 3, packet(s.Cfg.MaxHops, 1, 2, 3)); n != 1 {
+		t.Errorf("пакет с исчерпанным TTL: сброшено как петля %d, ожидался 1", n)
+	}
+	if n := loopDrops(s, 3, packet(2, 3, 2, 3)); n != 1 {
+		t.Errorf("повторный заход при жадной пересылке: сброшено как петля %d, ожидался 1", n)
+	}
+	if n := loopDrops(s, 3, packet(2, 1, 2, 3)); n != 0 {
+		t.Errorf("пакет без петли сброшен как петля")
+	}
+
+	// При обходе грани GPSR возврат на пройденный узел допустим, петлю ограничивает только TTL
+	perimeter := packet(2, 3, 2, 3)
+	perimeter.Perimeter = &models.Perimeter{Prev: 2}
+	if n := loopDrops(s, 3, perimeter); n != 0 {
+		t.Errorf("повторный заход при обходе грани сброшен как петля")
+	}
+	perimeter = packet(s.Cfg.MaxHops, 3, 2, 3)
+	perimeter.Perimeter = &models.Perimeter{Prev: 2}
+	if n := loopDrops(s, 3, perimeter); n != 1 {
+		t.Errorf("обход грани с исчерпанным TTL: сброшено как петля %d, ожидался 1", n)
+	}
+}
@@ -0,0 +1,342 @@
+package simulator
+
+import (
+	"drone_trust_sim/consensus"
+	"drone_trust_sim/models"
+	"slices"
+)
+
+// Модели MAC-уровня (SimulatorConfig.MACModel)
+const (
+	MACIdeal = "Ideal" // Без состязания: кадры уходят по очереди без отсрочки и коллизий
+	MACCSMA  = "CSMA"  // CSMA/CA: очередь узла, случайная отсрочка, контроль несущей и коллизии
+)
+
+// queued сообщает, проходят ли кадры через очереди узлов. Без LinkDataRates модель Ideal
+// сохраняет исходное поведение: кадр уходит сразу с фиксированной задержкой.
+func (s *Simulator) queued() bool {
+	return s.Cfg.MACModel == MACCSMA || len(s.Cfg.LinkDataRates) > 0
+}
+
+// airtime - время передачи пакета по линии длины distance: размер, деленный на скорость
+// полосы расстояния (CommunicationRadius делится на len(LinkDataRates) равных полос,
+// дальше радиуса - последняя). Без заданных скоростей - фиксированное MACFrameTime.
+func (s *Simulator) airtime(packet *models.Packet, distance float64) float64 {
+	rates := s.Cfg.LinkDataRates
+	if len(rates) == 0 {
+		return s.Cfg.MACFrameTime
+	}
+	band := int(distance / s.Cfg.CommunicationRadius * float64(len(rates)))
+	band = min(max(band, 0), len(rates)-1)
+	return float64(packet.Size*8) / rates[band]
+}
+
+// macFrame - кадр в очереди передачи узла
+type macFrame struct {
+	Receiver int // -1 - широковещательный кадр (служебные сообщения маршрутизации)
+	Packet   *models.Packet
+	Retries  int
+}
+
+// macNode - состояние MAC одного узла
+type macNode struct {
+	Queue   []macFrame
+	CW      int  // Текущее окно состязания, слотов
+	Pending bool // Для узла запланировано событие MAC (отсрочка или передача)
+}
+
+// transmission - кадр, находящийся в эфире
+type transmission struct {
+	ID        int
+	Sender    int
+	Frame     macFrame
+	End       float64
+	Receivers []int // Адресат кадра или, для широковещательного, соседи отправителя в момент начала
+	Jammed    []int // Получатели, у которых прием разрушен наложением другой передачи
+}
+
+// consensusRound - раунд консенсуса, ожидающий доставки своих сообщений
+type consensusRound struct {
+	ClusterID int
+	Block     *models.Block
+	Pending   int     // Сообщения, еще не доставленные и не потерянные
+	NotBefore float64 // Раунд не завершается раньше задержки, рассчитанной движком
+}
+
+// macState - состояние MAC-уровня всей сети; сохраняется в контрольных точках
+type macState struct {
+	Nodes  []macNode
+	Air    []*transmission // Передачи в эфире в порядке начала
+	NextTx int
+	Rounds map[int]*consensusRound // ID блока -> раунд
+}
+
+func newMACState(numNodes, cwMin int) macState {
+	st := macState{Nodes: make([]macNode, numNodes), Rounds: make(map[int]*consensusRound)}
+	for i := range st.Nodes {
+		st.Nodes[i].CW = cwMin
+	}
+	return st
+}
+
+// enqueueFrame ставит кадр для receiverID (-1 - всем соседям) в очередь отправителя и, если узел
+// простаивает, начинает доступ к каналу. При заполненной очереди (MACQueueCapacity) кадр отбрасывается.
+func (s *Simulator) enqueueFrame(sender *models.DroneNode, receiverID int, packet *models.Packet) {
+	st := &s.mac.Nodes[sender.ID]
+	if s.Cfg.MACQueueCapacity > 0 && len(st.Queue) >= s.Cfg.MACQueueCapacity {
+		switch packet.Kind {
+		case models.PacketConsensus:
+			s.consensusMessageDone(packet.BlockID)
+		case models.PacketData:
+			s.dropPacket(sender.ID, receiverID, packet, models.Failure_QueueOverflow)
+		}
+		return
+	}
+	st.Queue = append(st.Queue, macFrame{Receiver: receiverID, Packet: packet})
+	if !st.Pending {
+		s.scheduleAccess(sender.ID, s.CurrentTime)
+	}
+}
+
+// scheduleAccess планирует попытку доступа после случайной отсрочки из текущего окна, отсчитанной
+// от from. В модели Ideal отсрочки нет: следующий кадр уходит сразу за предыдущим.
+func (s *Simulator) scheduleAccess(nodeID int, from float64) {
+	st := &s.mac.Nodes[nodeID]
+	st.Pending = true
+	backoff := 0.0
+	if s.Cfg.MACModel == MACCSMA {
+		backoff = float64(s.Rng.IntN(st.CW)) * s.Cfg.MACSlotTime
+	}
+	s.scheduleEvent(&Event{Time: from + backoff, Type: EventMACAccess, NodeID: nodeID})
+}
+
+// handleMACAccess - отсрочка истекла: если соседи молчат, первый кадр очереди уходит в эфир,
+// иначе узел ждет освобождения канала и разыгрывает новую отсрочку.
+// В модели Ideal канал не прослушивается и коллизий нет.
+func (s *Simulator) handleMACAccess(nodeID int) {
+	node := s.Nodes[nodeID]
+	if len(s.mac.Nodes[nodeID].Queue) == 0 {
+		s.mac.Nodes[nodeID].Pending = false // Очередь сброшена после гибели узла
+		return
+	}
+	csma := s.Cfg.MACModel == MACCSMA
+	if csma {
+		busyUntil := -1.0
+		for _, tx := range s.mac.Air {
+			if tx.Sender != nodeID && s.IsNeighbor(s.Nodes[tx.Sender], node) {
+				busyUntil = max(busyUntil, tx.End)
+			}
+		}
+		if busyUntil >= 0 {
+			s.scheduleAccess(nodeID, busyUntil)
+			return
+		}
+	}
+
+	frame := s.mac.Nodes[nodeID].Queue[0]
+	var receivers []int
+	var distance float64
+	if frame.Receiver < 0 {
+		// Широковещательный кадр идет на скорости дальней полосы, чтобы его услышали все соседи
+		for _, n := range s.Neighbors(node) {
+			receivers = append(receivers, n.ID)
+		}
+		distance = s.Cfg.CommunicationRadius
+	} else {
+		receivers = []int{frame.Receiver}
+		distance = node.Location.Distance(s.Nodes[frame.Receiver].Location)
+	}
+	airtime := s.airtime(frame.Packet, distance)
+	tx := &transmission{ID: s.mac.NextTx, Sender: nodeID, Frame: frame, End: s.CurrentTime + airtime, Receivers: receivers}
+	s.mac.NextTx++
+	if csma {
+		s.markCollisions(tx)
+	}
+	s.mac.Air = append(s.mac.Air, tx)
+	s.scheduleEvent(&Event{Time: tx.End, Type: EventMACTxEnd, NodeID: nodeID, Data: tx.ID})
+}
+
+// markCollisions отмечает взаимные помехи новой передачи tx и передач, уже находящихся в эфире
+func (s *Simulator) markCollisions(tx *transmission) {
+	for _, other := range s.mac.Air {
+		s.interfere(tx, other)
+		s.interfere(other, tx)
+	}
+}
+
+// interfere отмечает получателей передачи victim, прием которых разрушает передача jammer:
+// получатель слышит ее отправителя (в том числе скрытый для отправителя victim терминал)
+// или сам сейчас передает (полудуплекс)
+func (s *Simulator) interfere(jammer, victim *transmission) {
+	source := s.Nodes[jammer.Sender]
+	for _, r := range victim.Receivers {
+		if (r == jammer.Sender || s.IsNeighbor(source, s.Nodes[r])) && !slices.Contains(victim.Jammed, r) {
+			victim.Jammed = append(victim.Jammed, r)
+		}
+	}
+}
+
+// handleMACTxEnd завершает передачу: кадр доставлен, отправлен повторно с удвоенным окном
+// или после MACMaxRetry повторов сброшен с причиной последней неудачи
+func (s *Simulator) handleMACTxEnd(txID int) {
+	idx := slices.IndexFunc(s.mac.Air, func(tx *transmission) bool { return tx.ID == txID })
+	tx := s.mac.Air[idx]
+	s.mac.Air = slices.Delete(s.mac.Air, idx, idx+1)
+	if tx.Frame.Receiver < 0 {
+		s.broadcastDone(tx)
+		return
+	}
+
+	sender, receiver := s.Nodes[tx.Sender], s.Nodes[tx.Frame.Receiver]
+	packet := tx.Frame.Packet
+	distance := sender.Location.Distance(receiver.Location)
+	outcome := models.InteractionSuccess
+	switch {
+	case !sender.Alive():
+		outcome = models.Failure_NodeDead // Узел погиб во время передачи
+	case len(tx.Jammed) > 0:
+		outcome = models.Failure_Collision
+	case !s.linkDelivers(distance):
+		outcome = models.Failure_OutOfRange
+	}
+
+	st := &s.mac.Nodes[tx.Sender]
+	retry := outcome == models.Failure_Collision || outcome == models.Failure_OutOfRange
+	if retry && s.Cfg.MACModel == MACCSMA && tx.Frame.Retries < s.Cfg.MACMaxRetry {
+		st.Queue[0].Retries++
+		st.CW = min(2*st.CW, s.Cfg.MACCWMax)
+		// Повторная передача тоже расходует энергию; если батарея села, очередь уже сброшена
+		if packet.Kind != models.PacketConsensus && !s.consumeEnergy(sender, s.Cfg.EnergyTx) {
+			return
+		}
+		s.scheduleAccess(tx.Sender, s.CurrentTime)
+		return
+	}
+
+	s.nextFrame(tx.Sender)
+
+	if outcome != models.InteractionSuccess {
+		s.linkFailed(sender, receiver, packet, outcome)
+		return
+	}
+	if packet.Kind == models.PacketConsensus {
+		s.consensusMessageDone(packet.BlockID)
+		return
+	}
+	if packet.Kind == models.PacketData {
+		s.packetForwarded(sender, receiver, packet)
+	}
+	s.scheduleEvent(&Event{
+		Time: s.CurrentTime + distance/300000000,
+		Type: EventPacketArrival,
+		Data: PacketArrivalData{NodeID: receiver.ID, Packet: packet},
+	})
+}
+
+// nextFrame убирает из очереди переданный кадр и начинает доступ к каналу для следующего
+func (s *Simulator) nextFrame(nodeID int) {
+	st := &s.mac.Nodes[nodeID]
+	st.Queue = st.Queue[1:]
+	st.CW = s.Cfg.MACCWMin
+	if len(st.Queue) > 0 {
+		s.scheduleAccess(nodeID, s.CurrentTime)
+	} else {
+		st.Pending = false
+	}
+}
+
+// broadcastDone завершает широковещательную передачу. Такой кадр не подтверждается
+// и не повторяется: его получает каждый сосед, у которого прием не разрушен наложением
+// и не потерян в канале.
+func (s *Simulator) broadcastDone(tx *transmission) {
+	s.nextFrame(tx.Sender)
+	sender := s.Nodes[tx.Sender]
+	if !sender.Alive() {
+		return
+	}
+	for _, id := range tx.Receivers {
+		distance := sender.Location.Distance(s.Nodes[id].Location)
+		if slices.Contains(tx.Jammed, id) || !s.linkDelivers(distance) {
+			continue
+		}
+		s.scheduleEvent(&Event{
+			Time: s.CurrentTime + distance/300000000,
+			Type: EventPacketArrival,
+			Data: PacketArrivalData{NodeID: id, Packet: tx.Frame.Packet},
+		})
+	}
+}
+
+// linkFailed обрабатывает окончательную потерю кадра на линии.
+// Гибель собственного узла не является наблюдением о получателе и на доверие не влияет.
+func (s *Simulator) linkFailed(sender, receiver *models.DroneNode, packet *models.Packet, reason models.InteractionResult) {
+	if packet.Kind == models.PacketConsensus {
+		s.consensusMessageDone(packet.BlockID)
+		return
+	}
+	// О разрыве линии протокол маршрутизации узнает от MAC-уровня
+	if reason == models.Failure_OutOfRange || reason == models.Failure_Collision {
+		s.Routing.LinkFailed(sender, receiver.ID, packet)
+	}
+	if packet.Kind == models.PacketRouting {
+		return
+	}
+	if reason != models.Failure_NodeDead {
+		s.recordInteraction(sender.ID, receiver.ID, reason)
+	}
+	s.dropPacket(sender.ID, receiver.ID, packet, reason)
+}
+
+// flushQueue сбрасывает очередь погибшего узла. Кадр, который сейчас в эфире, остается
+// в голове очереди и сбрасывается по окончании передачи.
+func (s *Simulator) flushQueue(node *models.DroneNode) {
+	st := &s.mac.Nodes[node.ID]
+	keep := 0
+	if slices.ContainsFunc(s.mac.Air, func(tx *transmission) bool { return tx.Sender == node.ID }) {
+		keep = 1
+	}
+	dropped := st.Queue[keep:]
+	st.Queue = st.Queue[:keep]
+	if keep == 0 {
+		st.Pending = false
+	}
+	for _, frame := range dropped {
+		if frame.Receiver < 0 {
+			continue // Широковещательный служебный кадр просто пропадает
+		}
+		s.linkFailed(node, s.Nodes[frame.Receiver], frame.Packet, models.Failure_NodeDead)
+	}
+}
+
+// startConsensusMessages передает сообщения раунда через MAC; раунд завершится, когда все
+// они будут доставлены или потеряны, но не раньше задержки, рассчитанной движком.
+// Потеря отдельных сообщений раунд не срывает: модель учитывает только занятость канала.
+func (s *Simulator) startConsensusMessages(clusterID int, block *models.Block, latency float64, msgs []consensus.Message) {
+	s.mac.Rounds[block.ID] = &consensusRound{ClusterID: clusterID, Block: block, Pending: len(msgs), NotBefore: s.CurrentTime + latency}
+	for _, msg := range msgs {
+		packet := &models.Packet{
+			ID:            s.GetNextPacketID(),
+			SourceID:      msg.From,
+			DestinationID: msg.To,
+			CreationTime:  s.CurrentTime,
+			Kind:          models.PacketConsensus,
+			BlockID:       block.ID,
+			Size:          s.Cfg.ControlPacketSize,
+		}
+		s.enqueueFrame(s.Nodes[msg.From], msg.To, packet)
+	}
+}
+
+func (s *Simulator) consensusMessageDone(blockID int) {
+	round := s.mac.Rounds[blockID]
+	round.Pending--
+	if round.Pending > 0 {
+		return
+	}
+	delete(s.mac.Rounds, blockID)
+	s.scheduleEvent(&Event{
+		Time: max(s.CurrentTime, round.NotBefore),
+		Type: EventConsensusEnd,
+		Data: ConsensusEndData{ClusterID: round.ClusterID, Block: round.Block},
+	})
+}
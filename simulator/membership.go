@@ -0,0 +1,233 @@
+package simulator
+
+import (
+	"container/heap"
+	"drone_trust_sim/models"
+	"drone_trust_sim/trace"
+	"slices"
+)
+
+// Действия запланированных событий состава роя (SimulatorConfig.MembershipEvents)
+const (
+	MembershipJoin  = "join"  // Вход нового дрона или возвращение ушедшего
+	MembershipLeave = "leave" // Уход с возможностью вернуться
+	MembershipCrash = "crash" // Необратимый отказ
+)
+
+var membershipEvents = map[string]EventType{
+	MembershipJoin:  EventNodeJoin,
+	MembershipLeave: EventNodeLeave,
+	MembershipCrash: EventNodeCrash,
+}
+
+// spawnGroundStation создает неподвижную наземную станцию в точке pos. Вычислительной
+// мощности в расчетах роя у нее нет: станция не выигрывает PoW и не сдвигает нормировку PoRS.
+func (s *Simulator) spawnGroundStation(id int, pos models.Point) *models.DroneNode {
+	return &models.DroneNode{
+		ID:              id,
+		Location:        pos,
+		Energy:          s.Cfg.InitialEnergy,
+		IsGroundStation: true,
+	}
+}
+
+// spawnNode создает узел со случайным положением и вычислительной мощностью
+func (s *Simulator) spawnNode(id int, malicious bool) *models.DroneNode {
+	cfg := s.Cfg
+	location := models.Point{X: s.Rng.Float64() * cfg.AreaWidth, Y: s.Rng.Float64() * cfg.AreaHeight, Z: cfg.AreaAltitudeMin}
+	computationalPower := cfg.MinCompPower + s.Rng.Float64()*(cfg.MaxCompPower-cfg.MinCompPower)
+	// Высоту разыгрываем только при заданном диапазоне, чтобы плоские сценарии
+	// с тем же зерном давали прежнюю последовательность случайных чисел
+	if cfg.AreaAltitudeMax > cfg.AreaAltitudeMin {
+		location.Z += s.Rng.Float64() * (cfg.AreaAltitudeMax - cfg.AreaAltitudeMin)
+	}
+	return &models.DroneNode{
+		ID:                 id,
+		IsMalicious:        malicious,
+		Location:           location,
+		ComputationalPower: computationalPower,
+		Energy:             cfg.InitialEnergy,
+	}
+}
+
+// scheduleMembership планирует запланированные изменения состава и первые события
+// пуассоновских потоков. При нулевых интенсивностях ГСЧ не используется.
+func (s *Simulator) scheduleMembership() {
+	for _, me := range s.Cfg.MembershipEvents {
+		s.scheduleEvent(&Event{Time: me.Time, Type: membershipEvents[me.Action], NodeID: me.NodeID, Data: false})
+	}
+	s.scheduleArrival(EventNodeJoin, s.Cfg.JoinRate)
+	s.scheduleArrival(EventNodeLeave, s.Cfg.LeaveRate)
+	s.scheduleArrival(EventNodeCrash, s.Cfg.CrashRate)
+}
+
+// scheduleArrival планирует следующее событие пуассоновского потока с интенсивностью rate.
+// Data = true отмечает, что после обработки поток продолжается.
+func (s *Simulator) scheduleArrival(eventType EventType, rate float64) {
+	if rate <= 0 {
+		return
+	}
+	s.scheduleEvent(&Event{Time: s.CurrentTime + s.Rng.ExpFloat64()/rate, Type: eventType, NodeID: -1, Data: true})
+}
+
+// handleMembership обрабатывает вход, уход или отказ узла. События, которые неприменимы
+// к текущему составу (уход уже выбывшего узла, возвращение работающего), пропускаются.
+func (s *Simulator) handleMembership(evt *Event) {
+	random, _ := evt.Data.(bool)
+	switch evt.Type {
+	case EventNodeJoin:
+		if random {
+			s.scheduleArrival(EventNodeJoin, s.Cfg.JoinRate)
+		}
+		if evt.NodeID < 0 {
+			s.addNode()
+		} else if evt.NodeID < len(s.Nodes) && s.Nodes[evt.NodeID].Status == models.NodeLeft {
+			s.returnNode(s.Nodes[evt.NodeID])
+		}
+
+	case EventNodeLeave:
+		if random {
+			s.scheduleArrival(EventNodeLeave, s.Cfg.LeaveRate)
+		}
+		node := s.pickMember(evt.NodeID)
+		if node == nil {
+			return
+		}
+		s.removeNode(node, models.NodeLeft)
+		s.Metrics.RecordLeave()
+		// Дроны, ушедшие случайно, возвращаются через экспоненциальное время;
+		// возвращение после запланированного ухода задается отдельным событием join
+		if random && s.Cfg.ReturnDelay > 0 {
+			s.scheduleEvent(&Event{Time: s.CurrentTime + s.Rng.ExpFloat64()*s.Cfg.ReturnDelay, Type: EventNodeJoin, NodeID: node.ID, Data: false})
+		}
+
+	case EventNodeCrash:
+		if random {
+			s.scheduleArrival(EventNodeCrash, s.Cfg.CrashRate)
+		}
+		if node := s.pickMember(evt.NodeID); node != nil {
+			s.removeNode(node, models.NodeCrashed)
+			s.Metrics.RecordCrash()
+		}
+	}
+}
+
+// pickMember возвращает работающий дрон с указанным ID или, при nodeID = -1, случайный работающий дрон.
+// Наземные станции рой не покидают.
+func (s *Simulator) pickMember(nodeID int) *models.DroneNode {
+	if nodeID >= 0 {
+		if nodeID < len(s.Nodes) && s.Nodes[nodeID].Alive() && !s.Nodes[nodeID].IsGroundStation {
+			return s.Nodes[nodeID]
+		}
+		return nil
+	}
+	var alive []*models.DroneNode
+	for _, n := range s.Nodes {
+		if n.Alive() && !n.IsGroundStation {
+			alive = append(alive, n)
+		}
+	}
+	if len(alive) == 0 {
+		return nil
+	}
+	return alive[s.Rng.IntN(len(alive))]
+}
+
+// addNode вводит в рой новый дрон с полной батареей. Он злонамерен с вероятностью MaliciousRatio
+// и попадает в кластер на ближайших перевыборах.
+func (s *Simulator) addNode() {
+	malicious := s.Cfg.MaliciousRatio > 0 && s.Rng.Float64() < s.Cfg.MaliciousRatio
+	node := s.spawnNode(len(s.Nodes), malicious)
+	s.Nodes = append(s.Nodes, node)
+	s.TrustManager.AddNode(node, s.CurrentTime)
+	s.ClusterManager.AddNode(node)
+	s.mac.Nodes = append(s.mac.Nodes, macNode{CW: s.Cfg.MACCWMin})
+	s.Mobility.Init(node, s.CurrentTime)
+	s.Index.Update(node.ID, node.Location)
+	s.Metrics.RecordJoin()
+	value := s.TrustManager.BootstrapTrust()
+	s.activateNode(node, &value)
+	s.noteNodeDistrust(node.ID)
+}
+
+// returnNode возвращает ушедший дрон с заряженной батареей в точку, откуда он ушел.
+// Доверие к нему и его собственные оценки восстанавливаются из архива менеджера доверия.
+func (s *Simulator) returnNode(node *models.DroneNode) {
+	// Израсходованное до подзарядки учитывается отдельно, иначе пропало бы из энергоэффективности
+	s.Metrics.RecordEnergyConsumed(s.Cfg.InitialEnergy - node.Energy)
+	node.Mutex.Lock()
+	node.Status = models.NodeActive
+	node.Energy = s.Cfg.InitialEnergy
+	node.Mutex.Unlock()
+	s.TrustManager.ReturnNode(node.ID)
+	s.Mobility.Init(node, s.CurrentTime)
+	s.Index.Update(node.ID, node.Location)
+	s.Metrics.RecordReturn()
+	s.activateNode(node, nil)
+	s.noteNodeDistrust(node.ID)
+}
+
+// activateNode фиксирует вход узла в трассе и запускает его перемещение, генерацию трафика
+// и периодические сообщения протокола маршрутизации.
+// bootstrap - начальное доверие роя к новому узлу (nil при возвращении).
+func (s *Simulator) activateNode(node *models.DroneNode, bootstrap *float64) {
+	pos := node.Location
+	s.emit(&trace.Record{Type: trace.TypeNodeJoin, Node: node.ID, Peer: -1, Pos: &pos, Malicious: node.IsMalicious, Value: bootstrap})
+	s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.MobilityUpdateInterval, Type: EventNodeMove, NodeID: node.ID, Data: s.CurrentTime})
+	s.scheduleEvent(&Event{Time: s.CurrentTime + s.Rng.Float64()*s.Cfg.PacketGenInterval, Type: EventPacketGenerate, NodeID: node.ID})
+	s.Routing.NodeJoined(node)
+}
+
+// removeNode выводит узел из сети со статусом status: он покидает кластер и расчеты доверия
+// (ушедший - с сохранением состояния до возвращения), его периодические события отменяются,
+// а очередь передачи и ожидающие маршрута пакеты сбрасываются
+func (s *Simulator) removeNode(node *models.DroneNode, status models.NodeStatus) {
+	node.Mutex.Lock()
+	node.Status = status
+	node.Mutex.Unlock()
+	s.cancelNodeEvents(node.ID)
+	s.Index.Remove(node.ID)
+	s.ClusterManager.RemoveNode(node.ID)
+	s.Metrics.RecordCHRoles(s.CurrentTime, s.ClusterHeads())
+	s.TrustManager.RemoveNode(node.ID, status == models.NodeLeft)
+	s.Routing.NodeRemoved(node)
+	s.dropPendingAcks(node.ID)
+
+	recType := trace.TypeNodeDeath
+	switch status {
+	case models.NodeLeft:
+		recType = trace.TypeNodeLeave
+	case models.NodeCrashed:
+		recType = trace.TypeNodeCrash
+	}
+	s.emit(&trace.Record{Type: recType, Node: node.ID, Peer: -1})
+	if status != models.NodeLeft {
+		for _, h := range s.hooks {
+			h.OnNodeDied(s.CurrentTime, node)
+		}
+	}
+	s.flushQueue(node)
+}
+
+// cancelNodeEvents удаляет из очереди перемещения, генерацию трафика, попытки доступа
+// к каналу, таймеры протокола маршрутизации и ожидания подтверждений узла. Иначе при быстром возвращении старые
+// цепочки событий шли бы рядом с новыми.
+// Окончание уже начатой передачи остается в очереди.
+func (s *Simulator) cancelNodeEvents(nodeID int) {
+	s.EventQueueMux.Lock()
+	defer s.EventQueueMux.Unlock()
+	s.EventQueue = slices.DeleteFunc(s.EventQueue, func(evt *Event) bool {
+		if evt.NodeID != nodeID {
+			return false
+		}
+		switch evt.Type {
+		case EventNodeMove, EventPacketGenerate, EventMACAccess, EventRoutingTimer, EventAckTimeout:
+			return true
+		}
+		return false
+	})
+	for i, evt := range s.EventQueue {
+		evt.index = i
+	}
+	heap.Init(&s.EventQueue)
+}
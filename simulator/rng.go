@@ -0,0 +1,13 @@
+package simulator
+
+import (
+	"math/rand/v2"
+)
+
+// newRand создает собственный ГСЧ прогона из зерна.
+// Один и тот же seed всегда дает одну и ту же последовательность.
+// Источник возвращается отдельно: его состояние сохраняется в контрольных точках.
+func newRand(seed int64) (*rand.Rand, *rand.PCG) {
+	src := rand.NewPCG(uint64(seed), uint64(seed)^0x9e3779b97f4a7c15)
+	return rand.New(src), src
+}
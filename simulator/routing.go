@@ -0,0 +1,72 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"drone_trust_sim/routing"
+)
+
+// Методы, через которые протокол маршрутизации обращается к сети (routing.Network)
+
+func (s *Simulator) GetNodeClusterHead(nodeID int) *models.DroneNode {
+	return s.ClusterManager.GetNodeClusterHead(nodeID)
+}
+
+// Trusted сообщает, проходит ли target фильтр доверия observer (RoutingTrustFilter)
+func (s *Simulator) Trusted(observerID, targetID int) bool {
+	return !s.Cfg.RoutingTrustFilter || s.TrustManager.GetTrust(observerID, targetID) >= s.Cfg.TrustThreshold
+}
+
+// SendControl передает служебный пакет маршрутизации соседу to или, при to = -1, всем соседям.
+// Пакет расходует энергию на передачу (однократно для широковещательного) и на прием у каждого получателя.
+func (s *Simulator) SendControl(from *models.DroneNode, to int, msg *models.RoutingMessage) {
+	if !from.Alive() || !s.consumeEnergy(from, s.Cfg.EnergyTx) {
+		return
+	}
+	packet := &models.Packet{
+		ID:            s.GetNextPacketID(),
+		SourceID:      from.ID,
+		DestinationID: to,
+		CreationTime:  s.CurrentTime,
+		Kind:          models.PacketRouting,
+		Size:          s.Cfg.ControlPacketSize,
+		Routing:       msg,
+	}
+	s.Metrics.RecordControlPacket()
+	if to >= 0 {
+		s.sendPacketToNextHop(from, s.Nodes[to], packet)
+		return
+	}
+	if s.queued() {
+		s.enqueueFrame(from, -1, packet)
+		return
+	}
+	for _, n := range s.Neighbors(from) {
+		distance := from.Location.Distance(n.Location)
+		if !s.linkDelivers(distance) {
+			continue
+		}
+		s.scheduleEvent(&Event{
+			Time: s.CurrentTime + 0.01 + distance/300000000,
+			Type: EventPacketArrival,
+			Data: PacketArrivalData{NodeID: n.ID, Packet: packet},
+		})
+	}
+}
+
+func (s *Simulator) ScheduleTimer(nodeID int, delay float64, timer routing.Timer) {
+	s.scheduleEvent(&Event{Time: s.CurrentTime + delay, Type: EventRoutingTimer, NodeID: nodeID, Data: timer})
+}
+
+// Forward продолжает пересылку пакета, ожидавшего маршрута. Энергия на передачу
+// уже списана, когда пакет пришел на узел.
+func (s *Simulator) Forward(node *models.DroneNode, packet *models.Packet) {
+	if !node.Alive() {
+		s.dropPacket(node.ID, -1, packet, models.Failure_NodeDead)
+		return
+	}
+	s.forward(node, packet)
+}
+
+func (s *Simulator) DropPacket(node *models.DroneNode, packet *models.Packet, reason models.InteractionResult) {
+	s.dropPacket(node.ID, -1, packet, reason)
+}
@@ -2,15 +2,23 @@ package simulator
 
 import (
 	"container/heap"
+	"drone_trust_sim/channel"
 	"drone_trust_sim/config"
 	"drone_trust_sim/consensus"
 	"drone_trust_sim/metrics"
+	"drone_trust_sim/mobility"
 	"drone_trust_sim/models"
 	"drone_trust_sim/routing"
+	"drone_trust_sim/spatial"
+	"drone_trust_sim/trace"
+	"drone_trust_sim/traffic"
 	"drone_trust_sim/trust"
 	"log"
-	"math/rand"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,42 +31,100 @@ type Simulator struct {
 	Metrics        *metrics.Collector
 	TrustManager   *trust.Manager
 	ClusterManager *routing.ClusterManager
-	Wg             sync.WaitGroup // Для ожидания завершения всех горутин
+	Routing        routing.Protocol
+	Mobility       mobility.Model
+	Traffic        traffic.Model
+	Channel        channel.Model
+	Index          *spatial.Grid // Пространственный индекс положений работающих узлов
 	PacketCounter  int
+	Seed           int64         // Фактически использованное зерно ГСЧ
+	Rng            *rand.Rand    // Собственный ГСЧ прогона, общий для всех подсистем
+	Trace          *trace.Writer // Запись трассы событий (nil - выключена)
+	hooks          []Hook        // Внешние наблюдатели (см. AddHook)
+	rngSrc         *rand.PCG     // Источник Rng; его состояние попадает в контрольные точки
+	mac            macState      // Очереди узлов и передачи в эфире
+	ack            ackState      // Ожидающие подтверждения пакеты и уже доставленные получателям
+	neighborRange  float64       // Радиус поиска соседей в индексе: дальше канал не дает MinLinkQuality
+	eventSeq       uint64
+	started        bool        // Начальные события уже запланированы (важно при возобновлении)
+	begun          bool        // Сеанс выполнения начат: состояние записано в трассу
+	pauseRequested atomic.Bool // Запрошена пауза (см. Pause)
 }
 
-func NewSimulator(cfg *config.SimulatorConfig) *Simulator {
-	rand.Seed(time.Now().UnixNano())
+func NewSimulator(cfg *config.SimulatorConfig) (*Simulator, error) {
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng, src := newRand(seed)
 	s := &Simulator{
 		Cfg:        cfg,
 		Metrics:    metrics.NewCollector(),
 		EventQueue: make(PriorityQueue, 0),
+		Seed:       seed,
+		Rng:        rng,
+		rngSrc:     src,
 	}
 
 	s.Nodes = make([]*models.DroneNode, cfg.NumDrones)
 	maliciousCount := int(float64(cfg.NumDrones) * cfg.MaliciousRatio)
 	for i := 0; i < cfg.NumDrones; i++ {
-		isMalicious := i < maliciousCount
-		s.Nodes[i] = &models.DroneNode{
-			ID:                 i,
-			IsMalicious:        isMalicious,
-			Location:           models.Point{X: rand.Float64() * cfg.AreaWidth, Y: rand.Float64() * cfg.AreaHeight},
-			ComputationalPower: cfg.MinCompPower + rand.Float64()*(cfg.MaxCompPower-cfg.MinCompPower),
-			Energy:             cfg.InitialEnergy,
-			PacketChannel:      make(chan *models.Packet, 100),
-		}
+		s.Nodes[i] = s.spawnNode(i, i < maliciousCount)
+	}
+	for _, pos := range cfg.GroundStations {
+		s.Nodes = append(s.Nodes, s.spawnGroundStation(len(s.Nodes), pos))
 	}
 
-	s.TrustManager = trust.NewManager(s.Nodes, cfg)
-	s.ClusterManager = routing.NewClusterManager(s.Nodes, cfg, s.TrustManager)
-
-	// Запускаем обработчики пакетов для каждого дрона в отдельной горутине
+	if err := s.attachManagers(); err != nil {
+		return nil, err
+	}
 	for _, node := range s.Nodes {
-		s.Wg.Add(1)
-		go s.nodePacketHandler(node)
+		if !node.IsGroundStation {
+			s.Mobility.Init(node, 0)
+		}
 	}
+	s.indexNodes()
 
-	return s
+	return s, nil
+}
+
+// attachManagers создает менеджеры доверия и кластеров, модели подвижности, канала
+// и трафика и протокол маршрутизации поверх уже заданных узлов
+func (s *Simulator) attachManagers() error {
+	tm, err := trust.NewManager(s.Nodes, s.Cfg)
+	if err != nil {
+		return err
+	}
+	s.TrustManager = tm
+	s.Index = spatial.NewGrid(s.Cfg.CommunicationRadius)
+	s.ClusterManager = routing.NewClusterManager(s.Nodes, s.Cfg, s.TrustManager, s.Index)
+	model, err := mobility.New(s.Cfg, s.Rng)
+	if err != nil {
+		return err
+	}
+	s.Mobility = model
+	load, err := traffic.New(s.Cfg, s.Rng)
+	if err != nil {
+		return err
+	}
+	s.Traffic = load
+	ch, err := channel.New(s.Cfg)
+	if err != nil {
+		return err
+	}
+	s.Channel = ch
+	s.neighborRange = channel.Range(ch, s.Cfg.MinLinkQuality, s.Cfg.CommunicationRadius)
+	proto, err := routing.New(s.Cfg, s)
+	if err != nil {
+		return err
+	}
+	s.Routing = proto
+	s.mac = newMACState(len(s.Nodes), s.Cfg.MACCWMin)
+	s.ack = newAckState()
+	return nil
 }
 
 func (s *Simulator) scheduleEvent(evt *Event) {
@@ -66,66 +132,120 @@ func (s *Simulator) scheduleEvent(evt *Event) {
 		log.Fatalf("FATAL: Attempted to schedule a nil event!")
 	}
 	s.EventQueueMux.Lock() // <<< ЗАХВАТЫВАЕМ МЬЮТЕКС
+	s.eventSeq++
+	evt.seq = s.eventSeq
 	heap.Push(&s.EventQueue, evt)
 	s.EventQueueMux.Unlock() // <<< ОСВОБОЖДАЕМ МЬЮТЕКС
 }
 
+// Run выполняет прогон до SimulationTime (или продолжает его после Step, RunUntil
+// и паузы) и возвращает итоговые метрики. Pause на Run не действует.
 func (s *Simulator) Run() *metrics.FinalMetrics {
 	// log.Println("Начало симуляции...")
+	s.advance(s.Cfg.SimulationTime, nil)
 
-	s.scheduleEvent(&Event{Time: 0, Type: EventCHReelection, Data: true})
-	for i := range s.Nodes {
-		s.scheduleEvent(&Event{Time: rand.Float64(), Type: EventNodeMove, NodeID: i})
-		s.scheduleEvent(&Event{Time: 1.0 + rand.Float64(), Type: EventPacketGenerate, NodeID: i})
+	// log.Println("Симуляция завершена. Расчет итоговых метрик.")
+	return s.Metrics.CalculateFinalMetrics(s)
+}
+
+// begin готовит сеанс выполнения: записывает в трассу текущее состояние и при первом
+// запуске планирует начальные события. Повторные вызовы ничего не делают.
+func (s *Simulator) begin() {
+	if s.begun {
+		return
+	}
+	s.begun = true
+	s.traceState()
+
+	// После возобновления из контрольной точки все события уже в очереди
+	if !s.started {
+		s.started = true
+		s.scheduleEvent(&Event{Time: 0, Type: EventCHReelection, Data: true})
+		for i, node := range s.Nodes {
+			if node.IsGroundStation {
+				continue // Станция неподвижна и только принимает трафик
+			}
+			s.scheduleEvent(&Event{Time: s.Rng.Float64(), Type: EventNodeMove, NodeID: i, Data: 0.0})
+			s.scheduleEvent(&Event{Time: 1.0 + s.Rng.Float64(), Type: EventPacketGenerate, NodeID: i})
+		}
+		s.scheduleCheckpoints()
+		s.scheduleSnapshots()
+		if s.Cfg.SampleInterval > 0 {
+			s.scheduleEvent(&Event{Time: s.Cfg.SampleInterval, Type: EventSample})
+		}
+		s.scheduleMembership()
+		s.Routing.Start()
+		for _, node := range s.Nodes {
+			s.noteNodeDistrust(node.ID)
+		}
 	}
+}
 
+// advance обрабатывает события до момента until включительно (но не дальше SimulationTime).
+// Перед каждым событием вызывается stop (если задана): ее ошибка прерывает цикл и возвращается.
+func (s *Simulator) advance(until float64, stop func() error) error {
+	s.begin()
+	until = min(until, s.Cfg.SimulationTime)
 	for {
-		s.EventQueueMux.Lock() // <<< ЗАХВАТЫВАЕМ МЬЮТЕКС ПЕРЕД ПРОВЕРКОЙ И ИЗВЛЕЧЕНИЕМ
-		if s.EventQueue.Len() == 0 {
-			s.EventQueueMux.Unlock() // <<< ОСВОБОЖДАЕМ, если очередь пуста
-			break
+		if stop != nil {
+			if err := stop(); err != nil {
+				return err
+			}
 		}
-
-		evt := heap.Pop(&s.EventQueue).(*Event)
-		s.EventQueueMux.Unlock() // <<< ОСВОБОЖДАЕМ СРАЗУ ПОСЛЕ ИЗВЛЕЧЕНИЯ
-
-		if evt.Time > s.Cfg.SimulationTime {
-			break
+		evt := s.popEvent(until)
+		if evt == nil {
+			return nil
 		}
 		s.CurrentTime = evt.Time
 		s.handleEvent(evt)
 	}
+}
 
-	time.Sleep(100 * time.Millisecond)
-	closeAllChannels(s.Nodes)
-	s.Wg.Wait()
-
-	// log.Println("Симуляция завершена. Расчет итоговых метрик.")
-	return s.Metrics.CalculateFinalMetrics(s)
+// popEvent извлекает ближайшее событие, если оно наступает не позже until, иначе возвращает nil.
+// События за пределами until остаются в очереди до следующего вызова.
+func (s *Simulator) popEvent(until float64) *Event {
+	s.EventQueueMux.Lock() // <<< ЗАХВАТЫВАЕМ МЬЮТЕКС ПЕРЕД ПРОВЕРКОЙ И ИЗВЛЕЧЕНИЕМ
+	defer s.EventQueueMux.Unlock()
+	if s.EventQueue.Len() == 0 || s.EventQueue[0].Time > until {
+		return nil
+	}
+	return heap.Pop(&s.EventQueue).(*Event)
 }
 
 func (s *Simulator) handleEvent(evt *Event) {
 	switch evt.Type {
 	case EventNodeMove:
 		node := s.Nodes[evt.NodeID]
+		if !node.Alive() {
+			return // Выбывший дрон больше не летает
+		}
+		lastMove, _ := evt.Data.(float64) // Момент предыдущего перемещения
 		node.Mutex.Lock()
-		node.Location.X += (rand.Float64() - 0.5) * 10
-		node.Location.Y += (rand.Float64() - 0.5) * 10
-		// Ограничение по полю
-		node.Location.X = models.Clamp(node.Location.X, 0, s.Cfg.AreaWidth)
-		node.Location.Y = models.Clamp(node.Location.Y, 0, s.Cfg.AreaHeight)
+		from := node.Location
+		s.Mobility.Move(node, s.CurrentTime)
 		node.Mutex.Unlock()
-		s.scheduleEvent(&Event{Time: s.CurrentTime + 1.0, Type: EventNodeMove, NodeID: evt.NodeID})
+		s.Index.Update(node.ID, node.Location)
+		s.traceMove(node)
+		if !s.consumeEnergy(node, s.flightEnergy(s.CurrentTime-lastMove, from.Distance(node.Location))) {
+			return
+		}
+		s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.MobilityUpdateInterval, Type: EventNodeMove, NodeID: evt.NodeID, Data: s.CurrentTime})
 
 	case EventPacketGenerate:
 		node := s.Nodes[evt.NodeID]
-		if node.IsClusterHead { // CH не генерируют пользовательский трафик
-			s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.PacketGenInterval, Type: EventPacketGenerate, NodeID: evt.NodeID})
+		if !node.Alive() {
+			return
+		}
+		next := &Event{Time: s.CurrentTime + s.Traffic.Next(node, s.CurrentTime), Type: EventPacketGenerate, NodeID: evt.NodeID}
+		// CH не генерируют пользовательский трафик; без живых адресатов пакет тоже не создается
+		if node.IsClusterHead || !s.hasAlivePeer(node.ID) {
+			s.scheduleEvent(next)
 			return
 		}
-		destID := rand.Intn(s.Cfg.NumDrones)
-		for destID == node.ID {
-			destID = rand.Intn(s.Cfg.NumDrones)
+		destID := s.Traffic.Destination(node, s.Nodes)
+		if destID < 0 {
+			s.scheduleEvent(next) // Например, нет работающей наземной станции
+			return
 		}
 
 		s.PacketCounter++
@@ -134,30 +254,40 @@ func (s *Simulator) handleEvent(evt *Event) {
 			SourceID:      node.ID,
 			DestinationID: destID,
 			CreationTime:  s.CurrentTime,
+			Size:          s.Cfg.DataPacketSize,
+			Path:          []int{node.ID},
 		}
 		s.Metrics.RecordPacketSent()
 		node.Mutex.Lock()
 		node.PacketsSent++
 		node.Mutex.Unlock()
+		s.tracePacket(trace.TypePacketGenerate, node.ID, destID, packet)
+		for _, h := range s.hooks {
+			h.OnPacketSent(s.CurrentTime, packet)
+		}
+		s.awaitAck(packet)
 
-		// Отправляем пакет "в эфир" (в канал обработчика)
+		// Отправляем пакет "в эфир"
 		s.routePacket(node, packet)
-		s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.PacketGenInterval, Type: EventPacketGenerate, NodeID: evt.NodeID})
+		s.scheduleEvent(next)
 
 	case EventPacketArrival:
-		// Это событие теперь обрабатывается в nodePacketHandler
-		// Пакет просто отправляется в канал нужного узла
 		arrivalEventData := evt.Data.(PacketArrivalData)
-		s.Nodes[arrivalEventData.NodeID].PacketChannel <- arrivalEventData.Packet
+		s.receivePacket(s.Nodes[arrivalEventData.NodeID], arrivalEventData.Packet)
 
 	case EventCHReelection:
 		isInitial := evt.Data.(bool)
 		// log.Printf("t=%.2f: Переизбрание Глав Кластеров (CH)...", s.CurrentTime)
+		s.tickTrust()
 		s.ClusterManager.ReelectClusterHeads(s.CurrentTime, s.Metrics)
+		s.Metrics.RecordCHRoles(s.CurrentTime, s.ClusterHeads())
+		s.traceClusters()
+		s.notifyCHElected()
 
 		// Если это не первые выборы и алгоритм - блокчейн, запускаем консенсус
 		if !isInitial && s.Cfg.CHSelectionAlgorithm == "Blockchain" {
-			for clusterID := range s.ClusterManager.GetClusters() {
+			// Обходим кластеры в порядке ID, чтобы порядок событий не зависел от итерации по map
+			for _, clusterID := range slices.Sorted(maps.Keys(s.ClusterManager.GetClusters())) {
 				s.scheduleEvent(&Event{Time: s.CurrentTime, Type: EventConsensusStart, Data: clusterID})
 			}
 		}
@@ -166,11 +296,63 @@ func (s *Simulator) handleEvent(evt *Event) {
 
 	case EventConsensusStart:
 		clusterID := evt.Data.(int)
-		s.Wg.Add(1)
-		go consensus.RunConsensusRound(s.CurrentTime, clusterID, s, &s.Wg)
+		s.emit(&trace.Record{Type: trace.TypeConsensusStart, Node: -1, Peer: -1, Cluster: clusterID})
+		latency, block, msgs := consensus.RunConsensusRound(s.CurrentTime, clusterID, s)
+		for _, m := range s.ClusterManager.GetClusterMembers(clusterID) {
+			s.checkDepleted(m) // Участники раунда могли разрядиться на консенсусе
+		}
+		switch {
+		case block == nil:
+			s.emit(&trace.Record{Type: trace.TypeConsensusEnd, Node: -1, Peer: -1, Cluster: clusterID, Outcome: "failed"})
+			for _, h := range s.hooks {
+				h.OnConsensusRound(s.CurrentTime, clusterID, nil)
+			}
+		case s.queued() && len(msgs) > 0:
+			s.startConsensusMessages(clusterID, block, latency, msgs)
+		default:
+			s.scheduleEvent(&Event{Time: s.CurrentTime + latency, Type: EventConsensusEnd, Data: ConsensusEndData{ClusterID: clusterID, Block: block}})
+		}
+
+	case EventCheckpoint:
+		if periodic := evt.Data.(bool); periodic {
+			s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.CheckpointInterval, Type: EventCheckpoint, Data: true})
+		}
+		if err := s.SaveCheckpoint(s.checkpointPath()); err != nil {
+			log.Printf("t=%.2f: не удалось сохранить контрольную точку: %v", s.CurrentTime, err)
+		}
 
 	case EventConsensusEnd:
-		// Можно добавить логику обработки результатов консенсуса
+		data := evt.Data.(ConsensusEndData)
+		s.emit(&trace.Record{Type: trace.TypeConsensusEnd, Node: data.Block.ProposerID, Peer: -1, Cluster: data.ClusterID, Block: data.Block.ID, Outcome: "committed"})
+		for _, h := range s.hooks {
+			h.OnConsensusRound(s.CurrentTime, data.ClusterID, data.Block)
+		}
+
+	case EventMACAccess:
+		s.handleMACAccess(evt.NodeID)
+
+	case EventMACTxEnd:
+		s.handleMACTxEnd(evt.Data.(int))
+
+	case EventNodeJoin, EventNodeLeave, EventNodeCrash:
+		s.handleMembership(evt)
+
+	case EventRoutingTimer:
+		if node := s.Nodes[evt.NodeID]; node.Alive() {
+			s.Routing.Timer(node, evt.Data.(routing.Timer))
+		}
+
+	case EventAckTimeout:
+		s.handleAckTimeout(evt.Data.(int))
+
+	case EventSample:
+		s.Metrics.RecordSample(s.CurrentTime, s, len(s.ClusterManager.GetClusters()))
+		s.scheduleEvent(&Event{Time: s.CurrentTime + s.Cfg.SampleInterval, Type: EventSample})
+
+	case EventSnapshot:
+		if err := s.saveSnapshot(); err != nil {
+			log.Printf("t=%.2f: не удалось сохранить рисунок топологии: %v", s.CurrentTime, err)
+		}
 	}
 }
 
@@ -179,138 +361,174 @@ type PacketArrivalData struct {
 	Packet *models.Packet
 }
 
-// nodePacketHandler - горутина, которая обрабатывает пакеты для одного узла
-func (s *Simulator) nodePacketHandler(node *models.DroneNode) {
-	defer s.Wg.Done()
-	for packet := range node.PacketChannel {
-		node.Mutex.Lock()
-		node.Energy -= s.Cfg.EnergyRx
-		node.Mutex.Unlock()
+// ConsensusEndData - результат раунда консенсуса, фиксируемый в момент его завершения
+type ConsensusEndData struct {
+	ClusterID int
+	Block     *models.Block
+}
 
-		if node.IsMalicious && rand.Float64() < 0.7 {
-			// <<< ИЗМЕНЕНО: Передаем конкретную причину >>>
-			s.TrustManager.RecordInteraction(packet.SourceID, node.ID, models.Failure_MaliciousDrop, s.CurrentTime)
-			node.Mutex.Lock()
-			node.PacketsDroppedByMe++
-			node.Mutex.Unlock()
-			continue
+// receivePacket обрабатывает прибытие пакета на узел: прием, решение о сбросе и пересылку.
+// Вызывается только из цикла событий, поэтому порядок обработки полностью определяется очередью.
+func (s *Simulator) receivePacket(node *models.DroneNode, packet *models.Packet) {
+	if !node.Alive() || !s.consumeEnergy(node, s.Cfg.EnergyRx) {
+		if packet.Kind == models.PacketData {
+			s.dropPacket(node.ID, -1, packet, models.Failure_NodeDead)
 		}
+		return
+	}
+	if packet.Kind == models.PacketRouting {
+		s.Routing.HandleControl(node, packet.SourceID, packet.Routing)
+		return
+	}
+	packet.Path = append(packet.Path, node.ID)
+	s.tracePacket(trace.TypePacketArrival, node.ID, -1, packet)
 
-		if packet.DestinationID == node.ID {
-			s.Metrics.RecordPacketDelivered(s.CurrentTime - packet.CreationTime)
-			s.Nodes[packet.SourceID].Mutex.Lock()
-			s.Nodes[packet.SourceID].PacketsDelivered++
-			s.Nodes[packet.SourceID].Mutex.Unlock()
-			// <<< ИЗМЕНЕНО: Успешное взаимодействие (условно от лица получателя к отправителю) >>>
-			// В текущей модели мы оцениваем только пересылающие узлы, поэтому этот вызов можно убрать
-			// s.TrustManager.RecordInteraction(packet.SourceID, node.ID, models.InteractionSuccess, s.CurrentTime)
+	if node.IsMalicious && s.Rng.Float64() < 0.7 {
+		// <<< ИЗМЕНЕНО: Передаем конкретную причину >>>
+		s.recordInteraction(packet.SourceID, node.ID, models.Failure_MaliciousDrop)
+		node.Mutex.Lock()
+		node.PacketsDroppedByMe++
+		node.Mutex.Unlock()
+		s.dropPacket(node.ID, -1, packet, models.Failure_MaliciousDrop)
+		return
+	}
+
+	if packet.DestinationID == node.ID {
+		if packet.IsAck {
+			s.receiveAck(node, packet)
 		} else {
-			node.Mutex.Lock()
-			node.PacketsForwarded++
-			node.Mutex.Unlock()
-			s.routePacket(node, packet)
+			s.deliverData(node, packet)
 		}
+		// <<< ИЗМЕНЕНО: Успешное взаимодействие (условно от лица получателя к отправителю) >>>
+		// В текущей модели мы оцениваем только пересылающие узлы, поэтому этот вызов можно убрать
+		// s.TrustManager.RecordInteraction(packet.SourceID, node.ID, models.InteractionSuccess, s.CurrentTime)
+	} else {
+		node.Mutex.Lock()
+		node.PacketsForwarded++
+		node.Mutex.Unlock()
+		s.routePacket(node, packet)
+	}
+}
+
+// dropPacket фиксирует потерю пакета данных в метриках и трассе.
+// Потерянные подтверждения в Drops не входят: они сказываются только на повторах.
+func (s *Simulator) dropPacket(nodeID, peerID int, packet *models.Packet, reason models.InteractionResult) {
+	if !packet.IsAck {
+		s.recordDataDrop(packet.ID, reason)
+	}
+	s.tracePacketDrop(nodeID, peerID, packet, reason)
+	for _, h := range s.hooks {
+		h.OnPacketDropped(s.CurrentTime, nodeID, packet, reason)
 	}
+	s.ackLost(packet)
 }
 
-// routePacket отправляет пакет следующему узлу или напрямую, если в радиусе
+// routePacket отправляет пакет следующему узлу, выбранному протоколом маршрутизации
 func (s *Simulator) routePacket(sender *models.DroneNode, packet *models.Packet) {
-	sender.Mutex.Lock()
-	sender.Energy -= s.Cfg.EnergyTx
-	sender.Mutex.Unlock()
+	if !s.consumeEnergy(sender, s.Cfg.EnergyTx) {
+		s.dropPacket(sender.ID, -1, packet, models.Failure_NodeDead)
+		return
+	}
 
 	packet.Hops++
-	if packet.Hops > 15 {
+	// Исчерпанный TTL и повторный заход на узел, уже записанный в маршруте пакета,
+	// одинаково считаются петлей. При обходе грани GPSR пакет законно возвращается
+	// на пройденные узлы (например, u→v→u через висячее ребро), поэтому в этом режиме
+	// от петель защищает только TTL.
+	revisit := false
+	if packet.Perimeter == nil {
+		i := slices.Index(packet.Path, sender.ID)
+		revisit = i >= 0 && i < len(packet.Path)-1
+	}
+	if packet.Hops > s.Cfg.MaxHops || revisit {
+		s.dropPacket(sender.ID, -1, packet, models.Failure_PacketLoop)
 		return
 	}
+	s.forward(sender, packet)
+}
 
-	destinationNode := s.Nodes[packet.DestinationID]
-
-	// Шаг 1: Прямая доставка, если возможно. Это самый высокий приоритет.
-	if sender.Location.Distance(destinationNode.Location) <= s.Cfg.CommunicationRadius {
-		// Записываем успешное взаимодействие с конечным узлом, если он не отправитель
-		// Это поможет поддерживать доверие в сети
-		if sender.ID != packet.SourceID {
-			s.TrustManager.RecordInteraction(sender.ID, destinationNode.ID, models.InteractionSuccess, s.CurrentTime)
-		}
-		s.sendPacketToNextHop(sender, destinationNode, packet)
+// forward передает пакет следующему хопу, если протокол его нашел и не оставил пакет у себя
+func (s *Simulator) forward(sender *models.DroneNode, packet *models.Packet) {
+	next, held := s.Routing.Route(sender, packet)
+	if held {
 		return
 	}
-
-	// Шаг 2: Определяем, является ли маршрутизация внутри- или межкластерной
-	senderClusterID := sender.ClusterID
-	destClusterID := destinationNode.ClusterID
-
-	var routingTarget *models.DroneNode // Точка, к которой мы стремимся на этом шаге
-	isInterCluster := false
-
-	if senderClusterID != -1 && senderClusterID == destClusterID {
-		// --- ВНУТРИКЛАСТЕРНАЯ МАРШРУТИЗАЦИЯ ---
-		routingTarget = destinationNode
-	} else {
-		// --- МЕЖКЛАСТЕРНАЯ МАРШРУТИЗАЦИЯ ---
-		isInterCluster = true
-		destCH := s.ClusterManager.GetNodeClusterHead(destinationNode.ID)
-		if destCH == nil {
-			// Если у цели нет CH, пытаемся идти напрямую к цели
-			routingTarget = destinationNode
-		} else {
-			routingTarget = destCH
-		}
+	if next == nil {
+		// Если ни один из вариантов не сработал, пакет теряется.
+		s.dropPacket(sender.ID, -1, packet, models.Failure_NoRoute)
+		return
 	}
+	// Выбор следующего узла записывается как успешное взаимодействие с ним; это помогает
+	// поддерживать доверие в сети. Прямую доставку от самого источника не учитываем.
+	if next.ID != packet.DestinationID || sender.ID != packet.SourceID {
+		s.recordInteraction(sender.ID, next.ID, models.InteractionSuccess)
+	}
+	s.Metrics.RecordTransmission(packet.Size, packet.IsAck)
+	s.sendPacketToNextHop(sender, next, packet)
+}
 
-	// Шаг 3: Жадный поиск лучшего следующего узла, который ближе к routingTarget
-	var bestNextHop *models.DroneNode
-	minDistToTarget := sender.Location.Distance(routingTarget.Location)
-
-	for _, potentialHop := range s.Nodes {
-		if potentialHop.ID == sender.ID || potentialHop.ID == destinationNode.ID {
-			continue
-		}
-
-		if sender.Location.Distance(potentialHop.Location) > s.Cfg.CommunicationRadius {
-			continue
-		}
+// IsNeighbor сообщает, достаточно ли качество канала между узлами, чтобы
+// маршрутизация считала их соседями (порог MinLinkQuality)
+func (s *Simulator) IsNeighbor(a, b *models.DroneNode) bool {
+	return s.Channel.ReceptionProbability(a.Location.Distance(b.Location)) >= s.Cfg.MinLinkQuality
+}
 
-		if s.TrustManager.GetTrust(sender.ID, potentialHop.ID) < s.Cfg.TrustThreshold {
-			continue
+// Neighbors возвращает работающих соседей узла (см. IsNeighbor) в порядке ID.
+// Кандидаты берутся из пространственного индекса в радиусе действия канала.
+func (s *Simulator) Neighbors(node *models.DroneNode) []*models.DroneNode {
+	var out []*models.DroneNode
+	for _, id := range s.Index.Within(node.Location, s.neighborRange) {
+		if other := s.Nodes[id]; id != node.ID && s.IsNeighbor(node, other) {
+			out = append(out, other)
 		}
+	}
+	return out
+}
 
-		distFromHopToTarget := potentialHop.Location.Distance(routingTarget.Location)
-		if distFromHopToTarget < minDistToTarget {
-			minDistToTarget = distFromHopToTarget
-			bestNextHop = potentialHop
+// indexNodes заносит в пространственный индекс все работающие узлы
+func (s *Simulator) indexNodes() {
+	for _, n := range s.Nodes {
+		if n.Alive() {
+			s.Index.Update(n.ID, n.Location)
 		}
 	}
+}
 
-	// Шаг 4: Отправка
-	if bestNextHop != nil {
-		s.TrustManager.RecordInteraction(sender.ID, bestNextHop.ID, models.InteractionSuccess, s.CurrentTime)
-		s.sendPacketToNextHop(sender, bestNextHop, packet)
-	} else if isInterCluster {
-		// Если не нашли "транзитный" узел, попробуем отправить напрямую Главе своего кластера в надежде, что он знает путь
-		senderCH := s.ClusterManager.GetNodeClusterHead(sender.ID)
-		if senderCH != nil && senderCH.ID != sender.ID {
-			s.TrustManager.RecordInteraction(sender.ID, senderCH.ID, models.InteractionSuccess, s.CurrentTime)
-			s.sendPacketToNextHop(sender, senderCH, packet)
-		}
+// linkDelivers разыгрывает прием пакета на расстоянии distance по модели канала.
+// При вероятности 0 или 1 ГСЧ не используется, поэтому UnitDisk не меняет последовательность случайных чисел.
+func (s *Simulator) linkDelivers(distance float64) bool {
+	p := s.Channel.ReceptionProbability(distance)
+	if p >= 1 {
+		return true
+	}
+	if p <= 0 {
+		return false
 	}
-	// Если ни один из вариантов не сработал, пакет теряется.
+	return s.Rng.Float64() < p
 }
 
 // sendPacketToNextHop - вспомогательная функция для отправки пакета
 func (s *Simulator) sendPacketToNextHop(sender, receiver *models.DroneNode, packet *models.Packet) {
+	if s.queued() {
+		s.enqueueFrame(sender, receiver.ID, packet)
+		return
+	}
 	distance := sender.Location.Distance(receiver.Location)
 
-	if distance > s.Cfg.CommunicationRadius {
+	if !s.linkDelivers(distance) {
 		// <<< ИЗМЕНЕНО: Записываем потерю из-за разрыва связи >>>
-		s.TrustManager.RecordInteraction(sender.ID, receiver.ID, models.Failure_OutOfRange, s.CurrentTime)
+		// Потеря в канале (в том числе вне радиуса) учитывается как Failure_OutOfRange,
+		// чтобы модели доверия могли отличать ее от злонамеренного сброса
+		s.linkFailed(sender, receiver, packet, models.Failure_OutOfRange)
 		return
 	}
 
 	// Эмулируем задержку передачи
 	delay := 0.01 + distance/300000000 // Базовая + расстояние/скорость_света (более реалистично)
 
+	if packet.Kind == models.PacketData {
+		s.packetForwarded(sender, receiver, packet)
+	}
 	s.scheduleEvent(&Event{
 		Time: s.CurrentTime + delay,
 		Type: EventPacketArrival,
@@ -318,22 +536,6 @@ func (s *Simulator) sendPacketToNextHop(sender, receiver *models.DroneNode, pack
 	})
 }
 
-func closeAllChannels(nodes []*models.DroneNode) {
-	for _, n := range nodes {
-		if n.PacketChannel != nil {
-			// Безопасное закрытие канала
-			func() {
-				defer func() {
-					if recover() != nil {
-						// Канал уже закрыт, ничего не делаем
-					}
-				}()
-				close(n.PacketChannel)
-			}()
-		}
-	}
-}
-
 func (s *Simulator) GetClusterHead(clusterID int) *models.DroneNode {
 	return s.ClusterManager.GetClusterHead(clusterID)
 }
@@ -355,6 +557,12 @@ func (s *Simulator) GetTrustManager() consensus.TrustManagerProvider {
 func (s *Simulator) GetNodes() []*models.DroneNode {
 	return s.Nodes
 }
+func (s *Simulator) GetRand() *rand.Rand {
+	return s.Rng
+}
+func (s *Simulator) GetSeed() int64 {
+	return s.Seed
+}
 func (s *Simulator) GetTrustManagerForMetrics() metrics.TrustManagerReader {
 	return s.TrustManager
 }
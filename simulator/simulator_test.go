@@ -0,0 +1,66 @@
+package simulator
+
+import (
+	"drone_trust_sim/config"
+	"drone_trust_sim/metrics"
+	"drone_trust_sim/routing"
+	"reflect"
+	"testing"
+)
+
+// testConfig возвращает короткий сценарий плана эксперимента: 50 дронов, поле 400x400,
+// 30% злонамеренных, алгоритм Base BTMSD, фиксированное зерно
+func testConfig(t *testing.T) *config.SimulatorConfig {
+	t.Helper()
+	for _, cfg := range config.GenerateExperimentConfigs() {
+		if cfg.NumDrones == 50 && cfg.AreaWidth == 400 && cfg.MaliciousRatio == 0.3 && cfg.AlgorithmName == "Base BTMSD" {
+			cfg.Seed = 42
+			cfg.SimulationTime = 30
+			return cfg
+		}
+	}
+	t.Fatal("в плане эксперимента нет тестового сценария")
+	return nil
+}
+
+func newTestSimulator(t *testing.T, cfg *config.SimulatorConfig) *Simulator {
+	t.Helper()
+	s, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// Каждый протокол маршрутизации доставляет трафик, учитывает каждый потерянный пакет
+// не больше одного раза и воспроизводим при том же зерне
+func TestRoutingProtocols(t *testing.T) {
+	for _, protocol := range []string{routing.ClusterGreedy, routing.AODV, routing.DSR, routing.OLSR, routing.GPSR} {
+		t.Run(protocol, func(t *testing.T) {
+			run := func() (*Simulator, *metrics.FinalMetrics) {
+				cfg := testConfig(t)
+				cfg.RoutingProtocol = protocol
+				s := newTestSimulator(t, cfg)
+				return s, s.Run()
+			}
+			s, first := run()
+			mc := s.Metrics
+			if mc.PacketsDelivered == 0 {
+				t.Fatalf("ни один из %d пакетов не доставлен", mc.PacketsSent)
+			}
+			drops := 0
+			for _, n := range mc.Drops {
+				drops += n
+			}
+			if mc.PacketsDelivered+drops > mc.PacketsSent {
+				t.Errorf("доставлено %d и потеряно %d из %d отправленных", mc.PacketsDelivered, drops, mc.PacketsSent)
+			}
+			if protocol != routing.ClusterGreedy && mc.ControlPackets == 0 {
+				t.Error("протокол не передал ни одного служебного пакета")
+			}
+			if _, second := run(); !reflect.DeepEqual(first, second) {
+				t.Error("повторный прогон с тем же зерном дал другие метрики")
+			}
+		})
+	}
+}
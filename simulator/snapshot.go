@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"drone_trust_sim/render"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// scheduleSnapshots планирует рисунки топологии по конфигурации
+func (s *Simulator) scheduleSnapshots() {
+	for _, t := range s.Cfg.SnapshotTimes {
+		s.scheduleEvent(&Event{Time: t, Type: EventSnapshot})
+	}
+}
+
+// saveSnapshot пишет рисунок текущей топологии в SnapshotDir
+func (s *Simulator) saveSnapshot() error {
+	if err := os.MkdirAll(s.Cfg.SnapshotDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(s.Cfg.SnapshotDir, fmt.Sprintf("topology_t%.2f.svg", s.CurrentTime)))
+	if err != nil {
+		return err
+	}
+	if err := s.WriteSVG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteSVG рисует текущую топологию роя в SVG (см. Scene)
+func (s *Simulator) WriteSVG(w io.Writer) error {
+	return render.SVG(w, s.Scene())
+}
+
+// Scene собирает состояние роя для рисунка: положения узлов, линии связи между
+// работающими соседями, кластеры и CH. Узел считается выявленным, если среднее
+// входящее доверие к нему ниже TrustThreshold; наземные станции не классифицируются.
+func (s *Simulator) Scene() *render.Scene {
+	sc := &render.Scene{
+		Title:    s.Cfg.AlgorithmName,
+		Time:     s.CurrentTime,
+		Width:    s.Cfg.AreaWidth,
+		Height:   s.Cfg.AreaHeight,
+		Clusters: s.Clusters(),
+	}
+	for _, n := range s.NodeViews() {
+		sc.Nodes = append(sc.Nodes, render.Node{
+			ID:        n.ID,
+			X:         n.Location.X,
+			Y:         n.Location.Y,
+			Alive:     n.Status == models.NodeActive,
+			Station:   n.IsGroundStation,
+			Head:      n.IsClusterHead,
+			Cluster:   n.ClusterID,
+			Malicious: n.IsMalicious,
+			Flagged:   !n.IsGroundStation && s.TrustManager.CalculateMeanIncomingTrust(n.ID) < s.Cfg.TrustThreshold,
+		})
+	}
+	for _, a := range s.Nodes {
+		if !a.Alive() {
+			continue
+		}
+		for _, b := range s.Neighbors(a) {
+			if a.ID < b.ID {
+				sc.Links = append(sc.Links, [2]int{a.ID, b.ID})
+			}
+		}
+	}
+	return sc
+}
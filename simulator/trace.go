@@ -0,0 +1,130 @@
+package simulator
+
+import (
+	"drone_trust_sim/models"
+	"drone_trust_sim/trace"
+	"maps"
+	"slices"
+)
+
+// emit пишет запись в трассу, если запись включена
+func (s *Simulator) emit(rec *trace.Record) {
+	if s.Trace == nil {
+		return
+	}
+	rec.Time = s.CurrentTime
+	s.Trace.Write(rec)
+}
+
+// traceState записывает заголовок трассы и полное текущее состояние: узлы, отличающиеся
+// от начальных значения доверия и кластеры. Для нового прогона это начальное состояние,
+// для возобновленного из контрольной точки - состояние на момент возобновления.
+func (s *Simulator) traceState() {
+	if s.Trace == nil {
+		return
+	}
+	s.emit(&trace.Record{Type: trace.TypeInit, Node: -1, Peer: -1,
+		Init: &trace.InitParams{NumNodes: len(s.Nodes), InitialTrustValue: s.Cfg.InitialTrustValue}})
+	for _, n := range s.Nodes {
+		pos := n.Location
+		s.emit(&trace.Record{Type: trace.TypeNode, Node: n.ID, Peer: -1, Pos: &pos, Malicious: n.IsMalicious, Station: n.IsGroundStation, Dead: !n.Alive()})
+	}
+	trustState := s.TrustManager.Snapshot()
+	for i, row := range trustState.TrustMatrix {
+		for j, value := range row {
+			if i == j || value == s.Cfg.InitialTrustValue {
+				continue
+			}
+			s.emit(&trace.Record{Type: trace.TypeTrustUpdate, Node: i, Peer: j, Value: &value})
+		}
+	}
+	if len(s.ClusterManager.GetClusters()) > 0 {
+		s.traceClusters()
+	}
+}
+
+func (s *Simulator) traceMove(node *models.DroneNode) {
+	pos := node.Location
+	s.emit(&trace.Record{Type: trace.TypeMove, Node: node.ID, Peer: -1, Pos: &pos})
+}
+
+// tracePacket записывает пакетное событие; peer = -1, если второй узел не участвует
+func (s *Simulator) tracePacket(recType string, nodeID, peerID int, packet *models.Packet) {
+	s.emit(&trace.Record{Type: recType, Node: nodeID, Peer: peerID, Packet: packet.ID})
+}
+
+func (s *Simulator) tracePacketDrop(nodeID, peerID int, packet *models.Packet, reason models.InteractionResult) {
+	s.emit(&trace.Record{Type: trace.TypePacketDrop, Node: nodeID, Peer: peerID, Packet: packet.ID, Outcome: reason.String()})
+}
+
+func (s *Simulator) traceClusters() {
+	if s.Trace == nil {
+		return
+	}
+	clusters := s.ClusterManager.GetClusters()
+	rec := &trace.Record{Type: trace.TypeCHReelection, Node: -1, Peer: -1,
+		Clusters: make(map[int][]int, len(clusters)), Heads: make(map[int]int)}
+	for _, clusterID := range slices.Sorted(maps.Keys(clusters)) {
+		ids := make([]int, 0, len(clusters[clusterID]))
+		for _, m := range clusters[clusterID] {
+			ids = append(ids, m.ID)
+		}
+		rec.Clusters[clusterID] = ids
+		if ch := s.ClusterManager.GetClusterHead(clusterID); ch != nil {
+			rec.Heads[clusterID] = ch.ID
+		}
+	}
+	s.emit(rec)
+}
+
+// tickTrust передает менеджеру доверия периодическое обновление. Значения, которые
+// модель при этом изменила (например, старение свидетельств модели Beta), записываются
+// в трассу, чтобы восстановленное по ней состояние совпадало с прогоном.
+func (s *Simulator) tickTrust() {
+	for _, c := range s.TrustManager.Tick(s.CurrentTime) {
+		s.noteDistrust(c.Observer, c.Target, c.Value)
+		s.emit(&trace.Record{Type: trace.TypeTrustUpdate, Node: c.Observer, Peer: c.Target, Value: &c.Value, Outcome: "aging"})
+	}
+}
+
+// recordInteraction передает наблюдение менеджеру доверия и фиксирует новое значение в трассе.
+// Все обновления доверия симулятора проходят через эту функцию. Наблюдения с участием
+// выбывших узлов (например, источника пакета, покинувшего рой) отбрасываются.
+// Здесь же отмечается момент, когда цель впервые признана недоверенной.
+func (s *Simulator) recordInteraction(observerID, targetID int, result models.InteractionResult) {
+	if !s.Nodes[observerID].Alive() || !s.Nodes[targetID].Alive() {
+		return
+	}
+	s.TrustManager.RecordInteraction(observerID, targetID, result, s.CurrentTime)
+	value := s.TrustManager.GetTrust(observerID, targetID)
+	s.noteDistrust(observerID, targetID, value)
+	if s.Trace == nil && len(s.hooks) == 0 {
+		return
+	}
+	s.emit(&trace.Record{Type: trace.TypeTrustUpdate, Node: observerID, Peer: targetID, Value: &value, Outcome: result.String()})
+	for _, h := range s.hooks {
+		h.OnTrustUpdated(s.CurrentTime, observerID, targetID, value, result)
+	}
+}
+
+// noteDistrust отмечает момент, когда цель впервые признана недоверенной: доверие к ней
+// работающего наблюдателя опустилось ниже TrustThreshold. Классификация та же, что
+// в FP/FN метрик: по каждой паре наблюдатель-цель.
+func (s *Simulator) noteDistrust(observerID, targetID int, value float64) {
+	if value < s.Cfg.TrustThreshold && observerID != targetID && s.Nodes[observerID].Alive() && s.Nodes[targetID].Alive() {
+		s.Metrics.RecordDistrust(s.CurrentTime, targetID)
+	}
+}
+
+// noteNodeDistrust проверяет доверие узла к работающим узлам и их доверие к нему. Нужно,
+// когда пары впервые попадают в классификацию: в начале прогона, при входе и возвращении узла
+// (например, если доверие к новичку NewcomerTrust уже ниже порога).
+func (s *Simulator) noteNodeDistrust(nodeID int) {
+	for _, other := range s.Nodes {
+		if other.ID == nodeID || !other.Alive() {
+			continue
+		}
+		s.noteDistrust(nodeID, other.ID, s.TrustManager.GetTrust(nodeID, other.ID))
+		s.noteDistrust(other.ID, nodeID, s.TrustManager.GetTrust(other.ID, nodeID))
+	}
+}
@@ -0,0 +1,70 @@
+package simulator
+
+import (
+	"bytes"
+	"drone_trust_sim/config"
+	"drone_trust_sim/trace"
+	"reflect"
+	"testing"
+)
+
+// checkReplay выполняет прогон с трассой и сверяет восстановленное по ней состояние
+// на конец прогона с состоянием симулятора
+func checkReplay(t *testing.T, cfg *config.SimulatorConfig, compareClusters bool) {
+	t.Helper()
+	s := newTestSimulator(t, cfg)
+	var buf bytes.Buffer
+	s.Trace = trace.NewWriter(&buf)
+	s.Run()
+	if err := s.Trace.Close(); err != nil {
+		t.Fatal(err)
+	}
+	st, err := trace.Replay(&buf, cfg.SimulationTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(st.Positions) != len(s.Nodes) {
+		t.Fatalf("в трассе %d узлов, в прогоне %d", len(st.Positions), len(s.Nodes))
+	}
+	for i, n := range s.Nodes {
+		if st.Positions[i] != n.Location {
+			t.Errorf("узел %d: положение %v, в прогоне %v", i, st.Positions[i], n.Location)
+		}
+		if st.Dead[i] == n.Alive() {
+			t.Errorf("узел %d: выбыл=%v, статус в прогоне %v", i, st.Dead[i], n.Status)
+		}
+	}
+	for i, row := range s.TrustManager.Snapshot().TrustMatrix {
+		if row != nil && !reflect.DeepEqual(row, st.Trust[i]) {
+			t.Errorf("доверие узла %d к остальным не совпадает с прогоном", i)
+		}
+	}
+	if compareClusters {
+		if !reflect.DeepEqual(st.Clusters, s.Clusters()) || !reflect.DeepEqual(st.Heads, s.ClusterHeads()) {
+			t.Error("кластеры и CH не совпадают с прогоном")
+		}
+	}
+}
+
+func TestReplayReproducesRun(t *testing.T) {
+	checkReplay(t, testConfig(t), true)
+}
+
+// Состав роя меняется: кластеры между перевыборами меняются без записи ch_reelection,
+// поэтому сверяются только узлы и доверие
+func TestReplayReproducesRunWithChurn(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.JoinRate, cfg.LeaveRate, cfg.CrashRate, cfg.ReturnDelay, cfg.NewcomerTrust = 0.2, 0.2, 0.05, 5, 0.4
+	checkReplay(t, cfg, false)
+}
+
+// Старение Beta меняет доверие без взаимодействий; трасса должна отражать и эти изменения
+func TestReplayReproducesBetaAging(t *testing.T) {
+	cfg := testConfig(t)
+	if err := config.ApplyAlgorithm(cfg, "Beta Reputation"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.BetaAging, cfg.CrashRate = 0.97, 0.05
+	checkReplay(t, cfg, false)
+}
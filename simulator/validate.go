@@ -0,0 +1,171 @@
+package simulator
+
+import (
+	"drone_trust_sim/channel"
+	"drone_trust_sim/config"
+	"drone_trust_sim/mobility"
+	"drone_trust_sim/routing"
+	"drone_trust_sim/traffic"
+	"drone_trust_sim/trust"
+	"fmt"
+)
+
+// ValidateConfig проверяет конфигурацию до запуска: названия подключаемых моделей
+// и их параметры. Ошибка возвращается сразу, а не проявляется посреди эксперимента.
+func ValidateConfig(cfg *config.SimulatorConfig) error {
+	if cfg.NumDrones <= 0 {
+		return fmt.Errorf("NumDrones должно быть положительным: %d", cfg.NumDrones)
+	}
+	if cfg.AreaWidth <= 0 || cfg.AreaHeight <= 0 {
+		return fmt.Errorf("размеры поля должны быть положительными: %.1fx%.1f", cfg.AreaWidth, cfg.AreaHeight)
+	}
+	if cfg.AreaAltitudeMax < cfg.AreaAltitudeMin {
+		return fmt.Errorf("неверный диапазон высот: [%v, %v]", cfg.AreaAltitudeMin, cfg.AreaAltitudeMax)
+	}
+
+	if !trust.Known(cfg.TrustModel) {
+		return fmt.Errorf("неизвестная модель доверия: %q (доступны: %v)", cfg.TrustModel, trust.Names())
+	}
+	if cfg.TrustModel == trust.Beta {
+		if cfg.BetaForgetting <= 0 || cfg.BetaForgetting > 1 {
+			return fmt.Errorf("BetaForgetting должен быть в (0, 1]: %v", cfg.BetaForgetting)
+		}
+		if cfg.BetaAging <= 0 || cfg.BetaAging > 1 {
+			return fmt.Errorf("BetaAging должен быть в (0, 1]: %v", cfg.BetaAging)
+		}
+	}
+
+	if !mobility.Known(cfg.MobilityModel) {
+		return fmt.Errorf("неизвестная модель подвижности: %q", cfg.MobilityModel)
+	}
+	if cfg.MobilityUpdateInterval <= 0 {
+		return fmt.Errorf("MobilityUpdateInterval должен быть положительным: %v", cfg.MobilityUpdateInterval)
+	}
+	if cfg.MinSpeed < 0 || cfg.MaxSpeed < cfg.MinSpeed {
+		return fmt.Errorf("неверный диапазон скоростей: [%v, %v]", cfg.MinSpeed, cfg.MaxSpeed)
+	}
+	if cfg.GaussMarkovAlpha < 0 || cfg.GaussMarkovAlpha > 1 {
+		return fmt.Errorf("GaussMarkovAlpha должен быть в [0, 1]: %v", cfg.GaussMarkovAlpha)
+	}
+	if cfg.MobilityModel == mobility.RPGM && cfg.RPGMGroupSize <= 0 {
+		return fmt.Errorf("RPGMGroupSize должен быть положительным: %d", cfg.RPGMGroupSize)
+	}
+	if cfg.MobilityModel == mobility.Trace && len(cfg.MobilityTraceFiles) == 0 {
+		return fmt.Errorf("для модели %s нужен хотя бы один файл в MobilityTraceFiles", mobility.Trace)
+	}
+
+	if !channel.Known(cfg.ChannelModel) {
+		return fmt.Errorf("неизвестная модель канала: %q", cfg.ChannelModel)
+	}
+	if cfg.MinLinkQuality <= 0 || cfg.MinLinkQuality > 1 {
+		return fmt.Errorf("MinLinkQuality должен быть в (0, 1]: %v", cfg.MinLinkQuality)
+	}
+	if cfg.ChannelModel != channel.UnitDisk {
+		if cfg.Frequency <= 0 || cfg.ReferenceDistance <= 0 {
+			return fmt.Errorf("Frequency и ReferenceDistance должны быть положительными: %v, %v", cfg.Frequency, cfg.ReferenceDistance)
+		}
+		if cfg.PathLossExponent <= 0 {
+			return fmt.Errorf("PathLossExponent должен быть положительным: %v", cfg.PathLossExponent)
+		}
+		if cfg.ShadowingSigma < 0 || cfg.RicianK < 0 {
+			return fmt.Errorf("ShadowingSigma и RicianK не могут быть отрицательными: %v, %v", cfg.ShadowingSigma, cfg.RicianK)
+		}
+	}
+
+	switch cfg.MACModel {
+	case MACIdeal:
+	case MACCSMA:
+		if cfg.MACSlotTime <= 0 || cfg.MACFrameTime <= 0 {
+			return fmt.Errorf("MACSlotTime и MACFrameTime должны быть положительными: %v, %v", cfg.MACSlotTime, cfg.MACFrameTime)
+		}
+		if cfg.MACCWMin < 1 || cfg.MACCWMax < cfg.MACCWMin {
+			return fmt.Errorf("неверное окно состязания: [%d, %d]", cfg.MACCWMin, cfg.MACCWMax)
+		}
+		if cfg.MACMaxRetry < 0 {
+			return fmt.Errorf("MACMaxRetry не может быть отрицательным: %d", cfg.MACMaxRetry)
+		}
+	default:
+		return fmt.Errorf("неизвестная модель MAC: %q", cfg.MACModel)
+	}
+	if cfg.DataPacketSize <= 0 || cfg.ControlPacketSize <= 0 {
+		return fmt.Errorf("размеры пакетов должны быть положительными: %d, %d", cfg.DataPacketSize, cfg.ControlPacketSize)
+	}
+	for _, rate := range cfg.LinkDataRates {
+		if rate <= 0 {
+			return fmt.Errorf("скорости линии должны быть положительными: %v", cfg.LinkDataRates)
+		}
+	}
+	if cfg.MACQueueCapacity < 0 {
+		return fmt.Errorf("MACQueueCapacity не может быть отрицательной: %d", cfg.MACQueueCapacity)
+	}
+
+	if !routing.Known(cfg.RoutingProtocol) {
+		return fmt.Errorf("неизвестный протокол маршрутизации: %q", cfg.RoutingProtocol)
+	}
+	if cfg.SampleInterval < 0 {
+		return fmt.Errorf("SampleInterval не может быть отрицательным: %v", cfg.SampleInterval)
+	}
+	if cfg.MaxHops <= 0 {
+		return fmt.Errorf("MaxHops должен быть положительным: %d", cfg.MaxHops)
+	}
+	if cfg.RoutingProtocol != routing.ClusterGreedy {
+		if cfg.HelloInterval <= 0 || cfg.TCInterval <= 0 {
+			return fmt.Errorf("HelloInterval и TCInterval должны быть положительными: %v, %v", cfg.HelloInterval, cfg.TCInterval)
+		}
+		if cfg.RouteTimeout <= 0 || cfg.DiscoveryTimeout <= 0 {
+			return fmt.Errorf("RouteTimeout и DiscoveryTimeout должны быть положительными: %v, %v", cfg.RouteTimeout, cfg.DiscoveryTimeout)
+		}
+		if cfg.DiscoveryRetries < 0 {
+			return fmt.Errorf("DiscoveryRetries не может быть отрицательным: %d", cfg.DiscoveryRetries)
+		}
+	}
+
+	if cfg.EndToEndAck {
+		if cfg.AckTimeout <= 0 || cfg.AckPacketSize <= 0 {
+			return fmt.Errorf("AckTimeout и AckPacketSize должны быть положительными: %v, %d", cfg.AckTimeout, cfg.AckPacketSize)
+		}
+		if cfg.MaxRetransmissions < 0 {
+			return fmt.Errorf("MaxRetransmissions не может быть отрицательным: %d", cfg.MaxRetransmissions)
+		}
+		if cfg.AckEvidenceWeight <= 0 || cfg.AckEvidenceWeight > 1 {
+			return fmt.Errorf("AckEvidenceWeight должен быть в (0, 1]: %v", cfg.AckEvidenceWeight)
+		}
+	}
+
+	if !traffic.Known(cfg.TrafficModel) {
+		return fmt.Errorf("неизвестная модель трафика: %q", cfg.TrafficModel)
+	}
+	if cfg.PacketGenInterval <= 0 {
+		return fmt.Errorf("PacketGenInterval должен быть положительным: %v", cfg.PacketGenInterval)
+	}
+	if cfg.TrafficModel == traffic.OnOff && (cfg.BurstInterval <= 0 || cfg.OnTime <= 0 || cfg.OffTime <= 0) {
+		return fmt.Errorf("BurstInterval, OnTime и OffTime должны быть положительными: %v, %v, %v", cfg.BurstInterval, cfg.OnTime, cfg.OffTime)
+	}
+	if cfg.TrafficModel == traffic.Convergecast && len(cfg.GroundStations) == 0 {
+		return fmt.Errorf("для модели %s нужна хотя бы одна наземная станция", traffic.Convergecast)
+	}
+	for _, p := range cfg.GroundStations {
+		if p.X < 0 || p.X > cfg.AreaWidth || p.Y < 0 || p.Y > cfg.AreaHeight || p.Z < 0 {
+			return fmt.Errorf("наземная станция вне поля: (%v, %v, %v)", p.X, p.Y, p.Z)
+		}
+	}
+
+	if cfg.JoinRate < 0 || cfg.LeaveRate < 0 || cfg.CrashRate < 0 {
+		return fmt.Errorf("интенсивности входа, ухода и отказов не могут быть отрицательными: %v, %v, %v", cfg.JoinRate, cfg.LeaveRate, cfg.CrashRate)
+	}
+	if cfg.ReturnDelay < 0 {
+		return fmt.Errorf("ReturnDelay не может быть отрицательным: %v", cfg.ReturnDelay)
+	}
+	if cfg.NewcomerTrust < 0 || cfg.NewcomerTrust > 1 {
+		return fmt.Errorf("NewcomerTrust должен быть в [0, 1]: %v", cfg.NewcomerTrust)
+	}
+	for _, me := range cfg.MembershipEvents {
+		if _, ok := membershipEvents[me.Action]; !ok {
+			return fmt.Errorf("неизвестное действие состава роя: %q", me.Action)
+		}
+		if me.Time < 0 || me.NodeID < -1 {
+			return fmt.Errorf("неверное событие состава роя %s: t=%v, узел %d", me.Action, me.Time, me.NodeID)
+		}
+	}
+	return nil
+}
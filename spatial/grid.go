@@ -0,0 +1,127 @@
+// Файл: spatial/grid.go
+package spatial
+
+import (
+	"drone_trust_sim/models"
+	"math"
+	"slices"
+)
+
+// Grid - пространственный индекс узлов: равномерная трехмерная сетка с ячейками
+// размера cellSize. Запрос соседей в радиусе порядка размера ячейки просматривает
+// только ближайшие ячейки, а не весь рой. Положения узлов обновляет владелец индекса
+// (симулятор - после каждого перемещения).
+type Grid struct {
+	cellSize float64
+	cells    map[cell][]int
+	items    map[int]item
+}
+
+type cell struct {
+	X, Y, Z int
+}
+
+type item struct {
+	Pos  models.Point
+	Cell cell
+}
+
+// NewGrid создает пустой индекс. Размер ячейки выбирают близким к типичному радиусу запроса.
+func NewGrid(cellSize float64) *Grid {
+	return &Grid{
+		cellSize: cellSize,
+		cells:    make(map[cell][]int),
+		items:    make(map[int]item),
+	}
+}
+
+func (g *Grid) cellOf(p models.Point) cell {
+	return cell{
+		X: int(math.Floor(p.X / g.cellSize)),
+		Y: int(math.Floor(p.Y / g.cellSize)),
+		Z: int(math.Floor(p.Z / g.cellSize)),
+	}
+}
+
+// Len - число узлов в индексе
+func (g *Grid) Len() int {
+	return len(g.items)
+}
+
+// Update добавляет узел в индекс или переносит его в новое положение
+func (g *Grid) Update(id int, pos models.Point) {
+	c := g.cellOf(pos)
+	if old, ok := g.items[id]; ok && old.Cell != c {
+		g.removeFromCell(id, old.Cell)
+		g.cells[c] = append(g.cells[c], id)
+	} else if !ok {
+		g.cells[c] = append(g.cells[c], id)
+	}
+	g.items[id] = item{Pos: pos, Cell: c}
+}
+
+// Remove исключает узел из индекса
+func (g *Grid) Remove(id int) {
+	old, ok := g.items[id]
+	if !ok {
+		return
+	}
+	g.removeFromCell(id, old.Cell)
+	delete(g.items, id)
+}
+
+func (g *Grid) removeFromCell(id int, c cell) {
+	ids := g.cells[c]
+	i := slices.Index(ids, id)
+	ids[i] = ids[len(ids)-1]
+	ids = ids[:len(ids)-1]
+	if len(ids) == 0 {
+		delete(g.cells, c)
+		return
+	}
+	g.cells[c] = ids
+}
+
+// Within возвращает ID узлов на расстоянии не больше radius от p (включая узел в самой точке)
+// в порядке возрастания ID, чтобы обход результата не зависел от устройства индекса.
+func (g *Grid) Within(p models.Point, radius float64) []int {
+	var ids []int
+	if !math.IsInf(radius, 1) {
+		// Блок ячеек берется с небольшим запасом, чтобы ошибки округления на его границе не теряли узлы
+		reach := radius * (1 + 1e-9)
+		lo := g.cellOf(models.Point{X: p.X - reach, Y: p.Y - reach, Z: p.Z - reach})
+		hi := g.cellOf(models.Point{X: p.X + reach, Y: p.Y + reach, Z: p.Z + reach})
+		// Если блок больше числа занятых ячеек, дешевле просмотреть узлы напрямую
+		if cellCount(lo, hi) <= len(g.cells) {
+			for x := lo.X; x <= hi.X; x++ {
+				for y := lo.Y; y <= hi.Y; y++ {
+					for z := lo.Z; z <= hi.Z; z++ {
+						for _, id := range g.cells[cell{x, y, z}] {
+							if g.items[id].Pos.Distance(p) <= radius {
+								ids = append(ids, id)
+							}
+						}
+					}
+				}
+			}
+			slices.Sort(ids)
+			return ids
+		}
+	}
+	for id, it := range g.items {
+		if it.Pos.Distance(p) <= radius {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// cellCount - число ячеек в прямоугольном блоке [lo, hi] (с насыщением, чтобы не переполниться)
+func cellCount(lo, hi cell) int {
+	n := 1.0
+	for _, d := range []int{hi.X - lo.X, hi.Y - lo.Y, hi.Z - lo.Z} {
+		n *= float64(d + 1)
+	}
+	return int(min(n, math.MaxInt32))
+}
@@ -0,0 +1,164 @@
+// Файл: trace/replay.go
+package trace
+
+import (
+	"bufio"
+	"drone_trust_sim/models"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// State - состояние роя, восстановленное из трассы на заданный момент времени
+type State struct {
+	Time      float64        `json:"time"`
+	Positions []models.Point `json:"positions"`
+	Malicious []bool         `json:"malicious"`
+	Stations  []bool         `json:"stations"`
+	Dead      []bool         `json:"dead"`
+	Trust     [][]float64    `json:"trust"`
+	Clusters  map[int][]int  `json:"clusters"`
+	Heads     map[int]int    `json:"heads"`
+
+	initialTrust float64 // Доверие новичка к остальным узлам (для записей node_join)
+}
+
+// Replay читает трассу и применяет все записи с временем не больше at.
+// Трасса должна начинаться с записи init, иначе начальное состояние неизвестно.
+func Replay(r io.Reader, at float64) (*State, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Записи ch_reelection для больших роев длинные
+
+	var st *State
+	line := 0
+	for scanner.Scan() {
+		line++
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+		if rec.Time > at {
+			break
+		}
+		if st == nil {
+			if rec.Type != TypeInit || rec.Init == nil {
+				return nil, fmt.Errorf("строка %d: трасса должна начинаться с записи %q", line, TypeInit)
+			}
+			st = newState(rec.Init)
+			continue
+		}
+		if err := st.apply(&rec); err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, fmt.Errorf("трасса пуста")
+	}
+	st.Time = at
+	return st, nil
+}
+
+func newState(p *InitParams) *State {
+	st := &State{
+		Positions: make([]models.Point, p.NumNodes),
+		Malicious: make([]bool, p.NumNodes),
+		Stations:  make([]bool, p.NumNodes),
+		Dead:      make([]bool, p.NumNodes),
+		Trust:     make([][]float64, p.NumNodes),
+		Clusters:  make(map[int][]int),
+		Heads:     make(map[int]int),
+
+		initialTrust: p.InitialTrustValue,
+	}
+	for i := range st.Trust {
+		st.Trust[i] = make([]float64, p.NumNodes)
+		for j := range st.Trust[i] {
+			if i == j {
+				st.Trust[i][j] = 1.0
+			} else {
+				st.Trust[i][j] = p.InitialTrustValue
+			}
+		}
+	}
+	return st
+}
+
+func (st *State) apply(rec *Record) error {
+	switch rec.Type {
+	case TypeNodeJoin:
+		// Новый узел получает следующий ID; иначе это возвращение ушедшего
+		if rec.Node == len(st.Positions) {
+			st.grow(rec.Value)
+		} else if !st.valid(rec.Node) {
+			return fmt.Errorf("узел вне диапазона: %d", rec.Node)
+		}
+	case TypeNode, TypeMove, TypeNodeDeath, TypeNodeLeave, TypeNodeCrash:
+		if !st.valid(rec.Node) {
+			return fmt.Errorf("узел вне диапазона: %d", rec.Node)
+		}
+	case TypeTrustUpdate:
+		if !st.valid(rec.Node) || !st.valid(rec.Peer) {
+			return fmt.Errorf("пара узлов вне диапазона: %d -> %d", rec.Node, rec.Peer)
+		}
+	}
+
+	switch rec.Type {
+	case TypeNode:
+		st.Malicious[rec.Node] = rec.Malicious
+		st.Stations[rec.Node] = rec.Station
+		st.Dead[rec.Node] = rec.Dead
+		if rec.Pos != nil {
+			st.Positions[rec.Node] = *rec.Pos
+		}
+	case TypeMove:
+		if rec.Pos != nil {
+			st.Positions[rec.Node] = *rec.Pos
+		}
+	case TypeNodeJoin:
+		st.Dead[rec.Node] = false
+		st.Malicious[rec.Node] = rec.Malicious
+		if rec.Pos != nil {
+			st.Positions[rec.Node] = *rec.Pos
+		}
+	case TypeNodeDeath, TypeNodeLeave, TypeNodeCrash:
+		st.Dead[rec.Node] = true
+	case TypeTrustUpdate:
+		if rec.Value != nil {
+			st.Trust[rec.Node][rec.Peer] = *rec.Value
+		}
+	case TypeCHReelection:
+		st.Clusters = rec.Clusters
+		st.Heads = rec.Heads
+	}
+	// Пакетные события и консенсус на восстанавливаемое состояние не влияют
+	return nil
+}
+
+// grow добавляет в состояние новый узел; bootstrap - доверие остальных к нему
+func (st *State) grow(bootstrap *float64) {
+	n := len(st.Positions)
+	st.Positions = append(st.Positions, models.Point{})
+	st.Malicious = append(st.Malicious, false)
+	st.Stations = append(st.Stations, false)
+	st.Dead = append(st.Dead, false)
+	value := st.initialTrust
+	if bootstrap != nil {
+		value = *bootstrap
+	}
+	for i := range st.Trust {
+		st.Trust[i] = append(st.Trust[i], value)
+	}
+	row := make([]float64, n+1)
+	for j := range row {
+		row[j] = st.initialTrust
+	}
+	row[n] = 1.0
+	st.Trust = append(st.Trust, row)
+}
+
+func (st *State) valid(id int) bool {
+	return id >= 0 && id < len(st.Positions)
+}
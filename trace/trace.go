@@ -0,0 +1,86 @@
+// Файл: trace/trace.go
+package trace
+
+import (
+	"bufio"
+	"drone_trust_sim/models"
+	"encoding/json"
+	"io"
+)
+
+// Типы записей трассы
+const (
+	TypeInit             = "init"              // Заголовок: число узлов и начальное доверие
+	TypeNode             = "node"              // Начальное состояние узла
+	TypeMove             = "move"              // Перемещение узла
+	TypePacketGenerate   = "packet_generate"   // Источник создал пакет
+	TypePacketArrival    = "packet_arrival"    // Пакет прибыл на узел
+	TypePacketForward    = "packet_forward"    // Узел передал пакет следующему хопу
+	TypePacketDeliver    = "packet_deliver"    // Пакет доставлен получателю
+	TypePacketDrop       = "packet_drop"       // Пакет потерян (причина в Outcome)
+	TypePacketAck        = "packet_ack"        // Источник получил сквозное подтверждение пакета (Peer - адресат)
+	TypePacketRetransmit = "packet_retransmit" // Источник повторил неподтвержденный пакет
+	TypeTrustUpdate      = "trust_update"      // Новое значение доверия observer -> target
+	TypeCHReelection     = "ch_reelection"     // Новое разбиение на кластеры и их главы
+	TypeConsensusStart   = "consensus_start"   // Начало раунда консенсуса в кластере
+	TypeConsensusEnd     = "consensus_end"     // Завершение раунда (блок и его автор)
+	TypeNodeDeath        = "node_death"        // Узел разрядил батарею и выбыл из сети
+	TypeNodeJoin         = "node_join"         // Вход нового узла (Value - начальное доверие роя к нему) или возвращение ушедшего
+	TypeNodeLeave        = "node_leave"        // Узел покинул рой и может вернуться
+	TypeNodeCrash        = "node_crash"        // Необратимый отказ узла
+)
+
+// Record - одна строка NDJSON-трассы.
+// Node/Peer равны -1, если не применимы; Packet равен 0, если запись не о пакете.
+type Record struct {
+	Time      float64       `json:"t"`
+	Type      string        `json:"type"`
+	Node      int           `json:"node"`
+	Peer      int           `json:"peer"`
+	Packet    int           `json:"packet,omitempty"`
+	Block     int           `json:"block,omitempty"`
+	Outcome   string        `json:"outcome,omitempty"`
+	Pos       *models.Point `json:"pos,omitempty"`
+	Value     *float64      `json:"value,omitempty"`
+	Malicious bool          `json:"malicious,omitempty"`
+	Dead      bool          `json:"dead,omitempty"`
+	Station   bool          `json:"station,omitempty"` // Наземная станция (запись node)
+	Cluster   int           `json:"cluster,omitempty"`
+	Clusters  map[int][]int `json:"clusters,omitempty"`
+	Heads     map[int]int   `json:"heads,omitempty"`
+	Init      *InitParams   `json:"init,omitempty"`
+}
+
+// InitParams - параметры, необходимые для восстановления начального состояния при воспроизведении
+type InitParams struct {
+	NumNodes          int     `json:"num_nodes"`
+	InitialTrustValue float64 `json:"initial_trust"`
+}
+
+// Writer пишет записи трассы построчно в формате NDJSON.
+// Первая ошибка записи запоминается и возвращается из Close.
+type Writer struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	buf := bufio.NewWriter(w)
+	return &Writer{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (w *Writer) Write(rec *Record) {
+	if w.err != nil {
+		return
+	}
+	w.err = w.enc.Encode(rec)
+}
+
+// Close сбрасывает буфер. Закрытие самого файла остается на вызывающей стороне.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.buf.Flush()
+}
@@ -0,0 +1,151 @@
+// Файл: traffic/traffic.go
+package traffic
+
+import (
+	"drone_trust_sim/config"
+	"drone_trust_sim/models"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+)
+
+// Model - модель пользовательского трафика: когда узел отправит следующий пакет и кому.
+// Next вызывается после каждого события генерации узла (в том числе пропущенного,
+// например, пока узел - CH) и возвращает интервал до следующего.
+// Destination возвращает ID адресата или -1, если отправлять некому.
+type Model interface {
+	Next(node *models.DroneNode, now float64) float64
+	Destination(node *models.DroneNode, nodes []*models.DroneNode) int
+	Snapshot() State
+	Restore(st State)
+}
+
+// NodeState - фаза источника On/Off
+type NodeState struct {
+	OnUntil float64 // Конец текущей (или последней) пачки
+}
+
+// State - сериализуемое состояние модели трафика (для контрольных точек)
+type State struct {
+	Nodes map[int]NodeState
+}
+
+// Названия моделей в SimulatorConfig.TrafficModel
+const (
+	CBR          = "CBR"          // Пакет каждые PacketGenInterval случайному адресату (исходное поведение)
+	Poisson      = "Poisson"      // Пуассоновский поток со средним интервалом PacketGenInterval
+	OnOff        = "OnOff"        // Пачки с интервалом BurstInterval, разделенные паузами
+	Convergecast = "Convergecast" // Телеметрия каждые PacketGenInterval на ближайшую наземную станцию
+)
+
+// Known сообщает, существует ли модель с таким названием
+func Known(name string) bool {
+	switch name {
+	case CBR, Poisson, OnOff, Convergecast:
+		return true
+	}
+	return false
+}
+
+// New создает модель трафика, выбранную в конфигурации
+func New(cfg *config.SimulatorConfig, rng *rand.Rand) (Model, error) {
+	b := newBase(cfg, rng)
+	switch cfg.TrafficModel {
+	case CBR:
+		return &ConstantRate{base: b}, nil
+	case Poisson:
+		return &PoissonModel{base: b}, nil
+	case OnOff:
+		return &Bursty{base: b}, nil
+	case Convergecast:
+		return &Telemetry{base: b}, nil
+	}
+	return nil, fmt.Errorf("неизвестная модель трафика: %q", cfg.TrafficModel)
+}
+
+// base - общие для всех моделей параметры и хранилище состояния
+type base struct {
+	cfg   *config.SimulatorConfig
+	rng   *rand.Rand
+	state State
+}
+
+func newBase(cfg *config.SimulatorConfig, rng *rand.Rand) base {
+	return base{cfg: cfg, rng: rng, state: State{Nodes: make(map[int]NodeState)}}
+}
+
+func (b *base) Snapshot() State {
+	return State{Nodes: maps.Clone(b.state.Nodes)}
+}
+
+func (b *base) Restore(st State) {
+	b.state = State{Nodes: maps.Clone(st.Nodes)}
+	if b.state.Nodes == nil {
+		b.state.Nodes = make(map[int]NodeState)
+	}
+}
+
+// Destination выбирает случайный работающий узел, кроме самого отправителя.
+// Вызывающий гарантирует, что такой узел есть.
+func (b *base) Destination(node *models.DroneNode, nodes []*models.DroneNode) int {
+	destID := b.rng.IntN(len(nodes))
+	for destID == node.ID || !nodes[destID].Alive() {
+		destID = b.rng.IntN(len(nodes))
+	}
+	return destID
+}
+
+// ConstantRate - исходная модель: постоянный интервал, адресат случаен
+type ConstantRate struct{ base }
+
+func (m *ConstantRate) Next(*models.DroneNode, float64) float64 {
+	return m.cfg.PacketGenInterval
+}
+
+// PoissonModel - экспоненциальные интервалы между пакетами
+type PoissonModel struct{ base }
+
+func (m *PoissonModel) Next(*models.DroneNode, float64) float64 {
+	return m.rng.ExpFloat64() * m.cfg.PacketGenInterval
+}
+
+// Bursty - источник On/Off: в пачке пакеты идут каждые BurstInterval, длительности
+// пачек и пауз между ними распределены экспоненциально со средними OnTime и OffTime
+type Bursty struct{ base }
+
+func (m *Bursty) Next(node *models.DroneNode, now float64) float64 {
+	st, ok := m.state.Nodes[node.ID]
+	if !ok {
+		st.OnUntil = now + m.rng.ExpFloat64()*m.cfg.OnTime
+	}
+	interval := m.cfg.BurstInterval
+	if now+interval > st.OnUntil {
+		// Пачка закончилась: следующий пакет открывает новую после паузы
+		start := max(now, st.OnUntil) + m.rng.ExpFloat64()*m.cfg.OffTime
+		st.OnUntil = start + m.rng.ExpFloat64()*m.cfg.OnTime
+		interval = start - now
+	}
+	m.state.Nodes[node.ID] = st
+	return interval
+}
+
+// Telemetry - сбор телеметрии (convergecast): каждый дрон периодически отправляет
+// пакет ближайшей работающей наземной станции
+type Telemetry struct{ base }
+
+func (m *Telemetry) Next(*models.DroneNode, float64) float64 {
+	return m.cfg.PacketGenInterval
+}
+
+func (m *Telemetry) Destination(node *models.DroneNode, nodes []*models.DroneNode) int {
+	best, bestDist := -1, 0.0
+	for _, n := range nodes {
+		if !n.IsGroundStation || !n.Alive() || n.ID == node.ID {
+			continue
+		}
+		if d := node.Location.Distance(n.Location); best < 0 || d < bestDist {
+			best, bestDist = n.ID, d
+		}
+	}
+	return best
+}
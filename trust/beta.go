@@ -0,0 +1,126 @@
+// Файл: trust/beta.go
+package trust
+
+import (
+	"bytes"
+	"drone_trust_sim/models"
+	"encoding/gob"
+	"math"
+)
+
+// Beta - байесовская модель бета-репутации (Jøsang, Ismail)
+const Beta = "Beta"
+
+// betaPriorWeight - вес априорного мнения W: столько свидетельств стоит базовое доверие пары
+const betaPriorWeight = 2.0
+
+func init() {
+	Register(Beta, func() Model { return &BetaModel{} })
+}
+
+// BetaModel - модель бета-репутации. Для каждой пары наблюдатель-цель копятся
+// положительные r и отрицательные s свидетельства; доверие - матожидание
+// бета-распределения (r + W*a) / (r + s + W), где a - базовое доверие пары
+// (значение матрицы до первого наблюдения: InitialTrustValue или доверие к новичку).
+// Успех - положительное свидетельство, злонамеренный сброс - отрицательное, сквозная
+// потеря (Failure_NoAck) - отрицательное с весом AckEvidenceWeight. Потери в канале
+// и на MAC-уровне нейтральны, как во всех моделях (см. isEvidence). Старые свидетельства
+// забываются: при каждом наблюдении умножаются на BetaForgetting, а со временем - на
+// BetaAging в секунду (при наблюдении и на периодическом обновлении перед перевыборами CH).
+// Поэтому доверие пары, переставшей взаимодействовать, возвращается к базовому.
+type BetaModel struct {
+	base
+	evidence map[[2]int]betaEvidence
+}
+
+// betaEvidence - свидетельства пары наблюдатель-цель
+type betaEvidence struct {
+	R, S float64 // Положительные и отрицательные свидетельства с учетом забывания
+	Base float64 // Базовое доверие a
+	Last float64 // Момент, до которого учтено старение
+}
+
+func (m *BetaModel) Init(tm *Manager) {
+	m.base.Init(tm)
+	m.evidence = make(map[[2]int]betaEvidence)
+}
+
+func (m *BetaModel) Update(observerID, targetID int, result models.InteractionResult, currentTime float64) {
+	var positive, negative float64
+	switch result {
+	case models.InteractionSuccess:
+		positive = 1
+	case models.Failure_MaliciousDrop:
+		negative = 1
+	case models.Failure_NoAck:
+		negative = m.tm.cfg.AckEvidenceWeight
+	default:
+		return // Нейтральный сбой не является свидетельством
+	}
+
+	tm := m.tm
+	key := [2]int{observerID, targetID}
+	ev, ok := m.evidence[key]
+	if !ok {
+		ev = betaEvidence{Base: tm.trustMatrix[observerID][targetID], Last: currentTime}
+	}
+
+	keep := tm.cfg.BetaForgetting * math.Pow(tm.cfg.BetaAging, currentTime-ev.Last)
+	ev.R = ev.R*keep + positive
+	ev.S = ev.S*keep + negative
+	ev.Last = currentTime
+
+	m.evidence[key] = ev
+	tm.trustMatrix[observerID][targetID] = ev.expectation()
+}
+
+// Tick старит свидетельства всех пар до момента currentTime и обновляет их доверие в матрице
+func (m *BetaModel) Tick(currentTime float64) []Change {
+	if m.tm.cfg.BetaAging == 1 {
+		return nil
+	}
+	var changes []Change
+	for key, ev := range m.evidence {
+		row := m.tm.trustMatrix[key[0]]
+		if row == nil {
+			delete(m.evidence, key) // Наблюдатель выбыл навсегда
+			continue
+		}
+		keep := math.Pow(m.tm.cfg.BetaAging, currentTime-ev.Last)
+		ev.R *= keep
+		ev.S *= keep
+		ev.Last = currentTime
+		m.evidence[key] = ev
+		if value := ev.expectation(); value != row[key[1]] {
+			row[key[1]] = value
+			changes = append(changes, Change{Observer: key[0], Target: key[1], Value: value})
+		}
+	}
+	return changes
+}
+
+// Uncertainty - неопределенность мнения observerID о targetID: W / (r + s + W).
+// Без свидетельств она равна 1 и убывает с их накоплением.
+func (m *BetaModel) Uncertainty(observerID, targetID int) float64 {
+	ev := m.evidence[[2]int{observerID, targetID}]
+	return betaPriorWeight / (ev.R + ev.S + betaPriorWeight)
+}
+
+func (ev betaEvidence) expectation() float64 {
+	return (ev.R + betaPriorWeight*ev.Base) / (ev.R + ev.S + betaPriorWeight)
+}
+
+func (m *BetaModel) Snapshot() []byte {
+	var buf bytes.Buffer
+	// Кодирование карты из чисел не завершается ошибкой
+	gob.NewEncoder(&buf).Encode(m.evidence)
+	return buf.Bytes()
+}
+
+func (m *BetaModel) Restore(data []byte) error {
+	m.evidence = make(map[[2]int]betaEvidence)
+	if len(data) == 0 {
+		return nil
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(&m.evidence)
+}
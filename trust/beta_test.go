@@ -0,0 +1,130 @@
+package trust
+
+import (
+	"drone_trust_sim/config"
+	"drone_trust_sim/models"
+	"math"
+	"testing"
+)
+
+func newTestManager(t *testing.T, model string, n int, tune func(*config.SimulatorConfig)) *Manager {
+	t.Helper()
+	cfg := &config.SimulatorConfig{
+		TrustModel:        model,
+		InitialTrustValue: 0.5,
+		AlphaTrust:        0.2,
+		AckEvidenceWeight: 0.5,
+		BetaForgetting:    1,
+		BetaAging:         1,
+	}
+	if tune != nil {
+		tune(cfg)
+	}
+	nodes := make([]*models.DroneNode, n)
+	for i := range nodes {
+		nodes[i] = &models.DroneNode{ID: i, Status: models.NodeActive}
+	}
+	tm, err := NewManager(nodes, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func assertTrust(t *testing.T, tm *Manager, observerID, targetID int, want float64) {
+	t.Helper()
+	if got := tm.GetTrust(observerID, targetID); math.Abs(got-want) > 1e-12 {
+		t.Errorf("доверие %d к %d = %v, ожидалось %v", observerID, targetID, got, want)
+	}
+}
+
+// Доверие - матожидание (r + W*a) / (r + s + W) с весом априорного мнения W = 2
+func TestBetaExpectation(t *testing.T) {
+	tm := newTestManager(t, Beta, 3, nil)
+	if u, ok := tm.Uncertainty(0, 1); !ok || u != 1 {
+		t.Fatalf("неопределенность без свидетельств = %v, %v", u, ok)
+	}
+	assertTrust(t, tm, 0, 1, 0.5)
+
+	tm.RecordInteraction(0, 1, models.InteractionSuccess, 0)
+	assertTrust(t, tm, 0, 1, (1+2*0.5)/3.0)
+	tm.RecordInteraction(0, 1, models.Failure_MaliciousDrop, 0)
+	assertTrust(t, tm, 0, 1, (1+2*0.5)/4.0)
+	tm.RecordInteraction(0, 1, models.Failure_NoAck, 0) // Вес AckEvidenceWeight
+	assertTrust(t, tm, 0, 1, (1+2*0.5)/4.5)
+	if u, _ := tm.Uncertainty(0, 1); math.Abs(u-2/4.5) > 1e-12 {
+		t.Errorf("неопределенность = %v, ожидалось %v", u, 2/4.5)
+	}
+	assertTrust(t, tm, 1, 0, 0.5) // Мнение в обратную сторону не меняется
+}
+
+// Потери в канале и на MAC-уровне свидетельствами не являются
+func TestBetaNeutralFailures(t *testing.T) {
+	tm := newTestManager(t, Beta, 2, nil)
+	for _, result := range []models.InteractionResult{models.Failure_OutOfRange, models.Failure_Collision,
+		models.Failure_QueueOverflow, models.Failure_NoRoute, models.Failure_PacketLoop} {
+		tm.RecordInteraction(0, 1, result, 0)
+	}
+	assertTrust(t, tm, 0, 1, 0.5)
+	if u, _ := tm.Uncertainty(0, 1); u != 1 {
+		t.Errorf("нейтральные сбои изменили неопределенность: %v", u)
+	}
+}
+
+// Свидетельства забываются с каждым наблюдением (BetaForgetting) и со временем (BetaAging)
+func TestBetaForgettingAndAging(t *testing.T) {
+	tm := newTestManager(t, Beta, 3, func(cfg *config.SimulatorConfig) {
+		cfg.BetaForgetting = 0.5
+		cfg.BetaAging = 0.5
+	})
+	tm.RecordInteraction(0, 1, models.InteractionSuccess, 0)
+	tm.RecordInteraction(0, 1, models.InteractionSuccess, 0) // r = 1*0.5 + 1
+	assertTrust(t, tm, 0, 1, (1.5+1)/3.5)
+
+	// Через 2 с без наблюдений r = 1.5 * 0.5^2
+	changes := tm.Tick(2)
+	want := (0.375 + 1) / 2.375
+	assertTrust(t, tm, 0, 1, want)
+	if len(changes) != 1 || changes[0] != (Change{Observer: 0, Target: 1, Value: want}) {
+		t.Errorf("Tick вернул %v", changes)
+	}
+	if changes := tm.Tick(2); len(changes) != 0 {
+		t.Errorf("повторный Tick в тот же момент изменил %v", changes)
+	}
+
+	// Наблюдение после паузы сначала старит свидетельства: r = 0.375 * 0.5^1 * 0.5, s = 1
+	tm.RecordInteraction(0, 1, models.Failure_MaliciousDrop, 3)
+	assertTrust(t, tm, 0, 1, (0.09375+1)/(0.09375+1+2))
+
+	// Без свидетельств доверие со временем возвращается к априорному
+	tm.Tick(200)
+	assertTrust(t, tm, 0, 1, 0.5)
+}
+
+// Без старения (BetaAging = 1) периодическое обновление ничего не меняет
+func TestBetaTickWithoutAging(t *testing.T) {
+	tm := newTestManager(t, Beta, 2, nil)
+	tm.RecordInteraction(0, 1, models.InteractionSuccess, 0)
+	if changes := tm.Tick(100); changes != nil {
+		t.Errorf("Tick без старения вернул %v", changes)
+	}
+	assertTrust(t, tm, 0, 1, 2/3.0)
+}
+
+// Свидетельства переживают контрольную точку
+func TestBetaSnapshotRestore(t *testing.T) {
+	tm := newTestManager(t, Beta, 2, func(cfg *config.SimulatorConfig) { cfg.BetaAging = 0.5 })
+	tm.RecordInteraction(0, 1, models.Failure_MaliciousDrop, 0)
+	st := tm.Snapshot()
+
+	restored := newTestManager(t, Beta, 2, func(cfg *config.SimulatorConfig) { cfg.BetaAging = 0.5 })
+	if err := restored.Restore(st); err != nil {
+		t.Fatal(err)
+	}
+	tm.Tick(1)
+	restored.Tick(1)
+	assertTrust(t, restored, 0, 1, tm.GetTrust(0, 1))
+	if u, _ := restored.Uncertainty(0, 1); u == 1 {
+		t.Error("свидетельства не восстановлены")
+	}
+}
@@ -17,17 +17,19 @@ func (tm *Manager) CalculateMeanIncomingTrust(candidateID int) float64 {
 	defer tm.RUnlock()
 
 	sum := 0.0
+	observers := 0
 	for i := range tm.nodes {
-		if i == candidateID {
+		if i == candidateID || !tm.active[i] {
 			continue
 		}
 		// Используем прямой доступ к матрице, т.к. уже под RLock
 		sum += tm.trustMatrix[i][candidateID]
+		observers++
 	}
-	if len(tm.nodes) <= 1 {
+	if observers == 0 {
 		return 0.0
 	}
-	return sum / float64(len(tm.nodes)-1)
+	return sum / float64(observers)
 }
 
 // CalculatePoRSScore (для PoRS)
@@ -101,6 +103,9 @@ func calculateDirectTrust_unsafe(tm *Manager, obsID, tgtID int, result models.In
 	case models.Failure_MaliciousDrop:
 		observation = 0.0
 		alpha = 0.7
+	case models.Failure_NoAck:
+		observation = 0.0
+		alpha = tm.alpha(result)
 	default:
 		return oldTrust
 	}
@@ -116,7 +121,7 @@ func calculateRecommendedTrust_unsafe(tm *Manager, obsID, tgtID int) float64 {
 	denominator := 0.0
 
 	for k := range tm.nodes {
-		if k == obsID || k == tgtID {
+		if k == obsID || k == tgtID || !tm.active[k] {
 			continue
 		}
 
@@ -168,36 +173,3 @@ func (tm *Manager) CalculateUnifiedScore(candidate *models.DroneNode) float64 {
 
 	return w_pors*porsScore + w_rf*rfScore
 }
-
-func calculateTrustByDefault_unsafe(tm *Manager, obsID, tgtID int, result models.InteractionResult, currentTime float64) float64 {
-	oldTrust := tm.trustMatrix[obsID][tgtID]
-
-	switch result {
-	case models.Failure_MaliciousDrop:
-		// Резко наказываем за доказанный злой умысел
-		// Можно использовать экспоненциальное наказание
-		return oldTrust * 0.5 // Каждый сброс режет доверие вдвое
-
-	case models.InteractionSuccess:
-		// Если узел был наказан, даем ему шанс медленно восстановиться
-		if oldTrust < tm.cfg.InitialTrustValue {
-			// Медленное восстановление, например, +0.01 за каждый успешный пакет
-			return models.Clamp(oldTrust+0.01, 0.0, tm.cfg.InitialTrustValue)
-		}
-		// Если доверие уже на максимуме, ничего не делаем
-		return oldTrust
-
-	default:
-		// Для всех остальных случаев (нейтральные сбои, отсутствие взаимодействий)
-		// НЕ МЕНЯЕМ ДОВЕРИЕ ВОВСЕ.
-		return oldTrust
-	}
-}
-
-// updateTrustByDefault_unsafe - обертка для новой модели
-func (tm *Manager) updateTrustByDefault_unsafe(observerID, targetID int, result models.InteractionResult, currentTime float64) {
-	// Для этой модели нам не нужны рекомендации, так как они могут быть источником FP.
-	// Полагаемся только на прямое наблюдение.
-	newTrust := calculateTrustByDefault_unsafe(tm, observerID, targetID, result, currentTime)
-	tm.trustMatrix[observerID][targetID] = newTrust
-}
@@ -3,6 +3,8 @@ package trust
 import (
 	"drone_trust_sim/config"
 	"drone_trust_sim/models"
+	"fmt"
+	"slices"
 	"sync"
 )
 
@@ -12,17 +14,29 @@ type Manager struct {
 	cfg            *config.SimulatorConfig
 	trustMatrix    [][]float64
 	lastUpdateTime [][]float64
+	active         []bool // Узел в составе роя; наблюдения и рекомендации отсутствующих не учитываются
+	model          Model  // Модель доверия из SimulatorConfig.TrustModel
+	modelName      string
 }
 
-func NewManager(nodes []*models.DroneNode, cfg *config.SimulatorConfig) *Manager {
+// NewManager создает менеджер с начальной матрицей доверия и моделью, выбранной в конфигурации
+func NewManager(nodes []*models.DroneNode, cfg *config.SimulatorConfig) (*Manager, error) {
+	model, err := newModel(cfg.TrustModel)
+	if err != nil {
+		return nil, err
+	}
 	n := len(nodes)
 	tm := &Manager{
 		nodes:          nodes,
 		cfg:            cfg,
 		trustMatrix:    make([][]float64, n),
 		lastUpdateTime: make([][]float64, n),
+		active:         make([]bool, n),
+		model:          model,
+		modelName:      cfg.TrustModel,
 	}
 	for i := range tm.trustMatrix {
+		tm.active[i] = true
 		tm.trustMatrix[i] = make([]float64, n)
 		tm.lastUpdateTime[i] = make([]float64, n)
 		for j := range tm.trustMatrix[i] {
@@ -33,59 +47,202 @@ func NewManager(nodes []*models.DroneNode, cfg *config.SimulatorConfig) *Manager
 			}
 		}
 	}
-	return tm
+	model.Init(tm)
+	return tm, nil
 }
 
-// RecordInteraction - главный метод для обновления доверия после взаимодействия
+// RecordInteraction - главный метод для обновления доверия после взаимодействия.
+// Нейтральные исходы (см. isEvidence) до модели не доходят.
 func (tm *Manager) RecordInteraction(observerID, targetID int, result models.InteractionResult, currentTime float64) {
+	if !isEvidence(result) {
+		return
+	}
 	tm.Lock()
 	defer tm.Unlock()
+	tm.model.Update(observerID, targetID, result, currentTime)
+}
 
-	// Новое, более гибкое условие
-	switch tm.cfg.TrustModel {
-	case "TrustByDefault":
-		tm.updateTrustByDefault_unsafe(observerID, targetID, result, currentTime)
-	case "Complex":
-		tm.updateComprehensiveTrust_unsafe(observerID, targetID, result, currentTime)
-	default: // "Simple"
-		success := result == models.InteractionSuccess
-		tm.updateSimpleTrust_unsafe(observerID, targetID, success)
+// isEvidence сообщает, говорит ли исход взаимодействия о поведении цели. Потери в канале
+// и на MAC-уровне (выход из радиуса, коллизии), переполнение очереди и отсутствие маршрута
+// случаются и с честными узлами, поэтому для всех моделей нейтральны.
+func isEvidence(result models.InteractionResult) bool {
+	switch result {
+	case models.InteractionSuccess, models.Failure_MaliciousDrop, models.Failure_NoAck:
+		return true
 	}
+	return false
 }
 
-// updateSimpleTrust_unsafe - внутренняя версия, работает без блокировки
-func (tm *Manager) updateSimpleTrust_unsafe(observerID, targetID int, success bool) {
-	oldTrust := tm.trustMatrix[observerID][targetID]
-	observation := 0.0
-	if success {
-		observation = 1.0
+// SetModel переключает менеджер на модель доверия name. Новая модель начинает
+// с текущей матрицы доверия; собственное состояние прежней модели отбрасывается.
+// Если модель уже выбрана, ее состояние сохраняется.
+func (tm *Manager) SetModel(name string) error {
+	tm.Lock()
+	defer tm.Unlock()
+	if name == tm.modelName {
+		return nil
+	}
+	model, err := newModel(name)
+	if err != nil {
+		return err
 	}
-	newTrust := (1-tm.cfg.AlphaTrust)*oldTrust + tm.cfg.AlphaTrust*observation
-	tm.trustMatrix[observerID][targetID] = models.Clamp(newTrust, 0, 1)
+	model.Init(tm)
+	tm.model, tm.modelName = model, name
+	return nil
 }
 
-// updateComprehensiveTrust_unsafe - внутренняя версия, работает без блокировки
-func (tm *Manager) updateComprehensiveTrust_unsafe(observerID, targetID int, result models.InteractionResult, currentTime float64) {
-	// 1. Рассчитываем прямой trust (T_d)
-	// <<< ИЗМЕНЕНО: передаем 'tm' в дочерние функции, чтобы они могли использовать его состояние >>>
-	directTrust := calculateDirectTrust_unsafe(tm, observerID, targetID, result)
+// Tick передает модели доверия периодическое обновление и возвращает измененные
+// им значения в порядке наблюдателя и цели
+func (tm *Manager) Tick(currentTime float64) []Change {
+	tm.Lock()
+	defer tm.Unlock()
+	changes := tm.model.Tick(currentTime)
+	slices.SortFunc(changes, func(a, b Change) int {
+		if a.Observer != b.Observer {
+			return a.Observer - b.Observer
+		}
+		return a.Target - b.Target
+	})
+	return changes
+}
 
-	// 2. Рассчитываем рекомендованный trust (T_re)
-	recommendedTrust := calculateRecommendedTrust_unsafe(tm, observerID, targetID)
+// alpha - скорость обучения для наблюдения. Сквозное свидетельство (Failure_NoAck) делится
+// между всеми узлами маршрута, поэтому его вес уменьшается на AckEvidenceWeight.
+func (tm *Manager) alpha(result models.InteractionResult) float64 {
+	if result == models.Failure_NoAck {
+		return tm.cfg.AlphaTrust * tm.cfg.AckEvidenceWeight
+	}
+	return tm.cfg.AlphaTrust
+}
 
-	// 3. Рассчитываем исторический trust (T_h)
-	historicalTrust := calculateHistoricalTrust_unsafe(tm, observerID, targetID, currentTime)
+// GetTrust - публичный, потокобезопасный метод для чтения.
+// Строка выбывшего навсегда узла освобождена: его мнение о других равно 0.
+func (tm *Manager) GetTrust(observerID, targetID int) float64 {
+	tm.RLock() // <<< Блокировка на ЧТЕНИЕ
+	defer tm.RUnlock()
+	if tm.trustMatrix[observerID] == nil {
+		return 0
+	}
+	return tm.model.Trust(observerID, targetID)
+}
 
-	// 4. Агрегируем все в комплексное доверие (T_total)
-	totalTrust := calculateTotalTrust(directTrust, recommendedTrust, historicalTrust)
+// Uncertainty возвращает неопределенность доверия observerID к targetID в [0, 1];
+// false - модель доверия неопределенность не оценивает
+func (tm *Manager) Uncertainty(observerID, targetID int) (float64, bool) {
+	tm.RLock()
+	defer tm.RUnlock()
+	est, ok := tm.model.(UncertaintyEstimator)
+	if !ok {
+		return 0, false
+	}
+	return est.Uncertainty(observerID, targetID), true
+}
 
-	tm.trustMatrix[observerID][targetID] = models.Clamp(totalTrust, 0, 1)
-	tm.lastUpdateTime[observerID][targetID] = currentTime
+// BootstrapTrust - начальное доверие роя к новому узлу: NewcomerTrust, а если оно не задано - InitialTrustValue
+func (tm *Manager) BootstrapTrust() float64 {
+	if tm.cfg.NewcomerTrust > 0 {
+		return tm.cfg.NewcomerTrust
+	}
+	return tm.cfg.InitialTrustValue
 }
 
-// GetTrust - публичный, потокобезопасный метод для чтения
-func (tm *Manager) GetTrust(observerID, targetID int) float64 {
-	tm.RLock() // <<< Блокировка на ЧТЕНИЕ
+// AddNode добавляет в матрицу новый узел; его ID должен совпадать с текущим числом узлов.
+// Рой доверяет новичку на BootstrapTrust, сам новичок доверяет остальным на InitialTrustValue.
+// Отсчет затухания для новых пар начинается с момента входа.
+func (tm *Manager) AddNode(node *models.DroneNode, currentTime float64) {
+	tm.Lock()
+	defer tm.Unlock()
+	n := len(tm.nodes)
+	bootstrap := tm.BootstrapTrust()
+	for i := range tm.trustMatrix {
+		if tm.trustMatrix[i] == nil {
+			continue // Строка выбывшего навсегда узла уже освобождена
+		}
+		tm.trustMatrix[i] = append(tm.trustMatrix[i], bootstrap)
+		tm.lastUpdateTime[i] = append(tm.lastUpdateTime[i], currentTime)
+	}
+	row := make([]float64, n+1)
+	updated := make([]float64, n+1)
+	for j := range row {
+		row[j] = tm.cfg.InitialTrustValue
+		updated[j] = currentTime
+	}
+	row[n] = 1.0
+	tm.nodes = append(tm.nodes, node)
+	tm.trustMatrix = append(tm.trustMatrix, row)
+	tm.lastUpdateTime = append(tm.lastUpdateTime, updated)
+	tm.active = append(tm.active, true)
+}
+
+// RemoveNode исключает узел из расчетов доверия. Если узел может вернуться (archive),
+// его строка и мнения роя о нем сохраняются до возвращения; иначе строка освобождается.
+func (tm *Manager) RemoveNode(nodeID int, archive bool) {
+	tm.Lock()
+	defer tm.Unlock()
+	tm.active[nodeID] = false
+	if !archive {
+		tm.trustMatrix[nodeID] = nil
+		tm.lastUpdateTime[nodeID] = nil
+	}
+}
+
+// ReturnNode возвращает в расчеты узел, ушедший с сохранением состояния
+func (tm *Manager) ReturnNode(nodeID int) {
+	tm.Lock()
+	defer tm.Unlock()
+	tm.active[nodeID] = true
+}
+
+// State - сериализуемое состояние менеджера доверия (для контрольных точек)
+type State struct {
+	TrustMatrix    [][]float64
+	LastUpdateTime [][]float64
+	Active         []bool
+	Model          []byte // Собственное состояние модели доверия (см. Model.Snapshot)
+}
+
+// Snapshot возвращает глубокую копию матрицы доверия, времен последних обновлений и состояния модели
+func (tm *Manager) Snapshot() State {
+	tm.RLock()
 	defer tm.RUnlock()
-	return tm.trustMatrix[observerID][targetID]
+	return State{
+		TrustMatrix:    copyMatrix(tm.trustMatrix),
+		LastUpdateTime: copyMatrix(tm.lastUpdateTime),
+		Active:         slices.Clone(tm.active),
+		Model:          tm.model.Snapshot(),
+	}
+}
+
+// Restore заменяет состояние менеджера сохраненным; размеры должны совпадать с числом узлов
+func (tm *Manager) Restore(st State) error {
+	tm.Lock()
+	defer tm.Unlock()
+	n := len(tm.nodes)
+	if len(st.TrustMatrix) != n || len(st.LastUpdateTime) != n {
+		return fmt.Errorf("размер матрицы доверия %d не совпадает с числом узлов %d", len(st.TrustMatrix), n)
+	}
+	tm.trustMatrix = copyMatrix(st.TrustMatrix)
+	tm.lastUpdateTime = copyMatrix(st.LastUpdateTime)
+	tm.active = slices.Clone(st.Active)
+	if tm.active == nil {
+		tm.active = slices.Repeat([]bool{true}, n)
+	}
+	if len(tm.active) != n {
+		return fmt.Errorf("признаки присутствия заданы для %d узлов, а не для %d", len(tm.active), n)
+	}
+	if err := tm.model.Restore(st.Model); err != nil {
+		return fmt.Errorf("не удалось восстановить состояние модели доверия: %w", err)
+	}
+	return nil
+}
+
+// copyMatrix копирует матрицу; пустые (освобожденные) строки остаются nil
+func copyMatrix(m [][]float64) [][]float64 {
+	out := make([][]float64, len(m))
+	for i := range m {
+		if len(m[i]) > 0 {
+			out[i] = append([]float64(nil), m[i]...)
+		}
+	}
+	return out
 }
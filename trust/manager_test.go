@@ -0,0 +1,27 @@
+package trust
+
+import (
+	"drone_trust_sim/models"
+	"testing"
+)
+
+// Потери в канале, коллизии и отсутствие маршрута не меняют доверие ни в одной модели,
+// а сброс пакета злоумышленником снижает его
+func TestNeutralFailuresKeepTrust(t *testing.T) {
+	neutral := []models.InteractionResult{models.Failure_OutOfRange, models.Failure_Collision,
+		models.Failure_QueueOverflow, models.Failure_NoRoute, models.Failure_PacketLoop}
+	for _, model := range []string{Simple, Complex, TrustByDefault, Beta} {
+		tm := newTestManager(t, model, 3, nil)
+		before := tm.GetTrust(0, 1)
+		for _, result := range neutral {
+			tm.RecordInteraction(0, 1, result, 1)
+		}
+		if got := tm.GetTrust(0, 1); got != before {
+			t.Errorf("%s: нейтральные сбои изменили доверие %v -> %v", model, before, got)
+		}
+		tm.RecordInteraction(0, 1, models.Failure_MaliciousDrop, 2)
+		if got := tm.GetTrust(0, 1); got >= before {
+			t.Errorf("%s: сброс пакета не снизил доверие: %v -> %v", model, before, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Файл: trust/model.go
+package trust
+
+import (
+	"drone_trust_sim/models"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Model - модель доверия: как наблюдения меняют доверие наблюдателя к цели.
+// Текущие значения доверия хранятся в матрице менеджера: по ней считаются среднее
+// входящее доверие, рекомендации и метрики, поэтому модель держит матрицу в актуальном
+// состоянии. Все методы вызываются под блокировкой менеджера и сами ее не берут.
+type Model interface {
+	// Init связывает модель с менеджером; вызывается один раз после заполнения начальной матрицы
+	Init(tm *Manager)
+	// Update пересчитывает доверие observerID к targetID после взаимодействия
+	Update(observerID, targetID int, result models.InteractionResult, currentTime float64)
+	// Tick - периодическое обновление всех оценок; симулятор вызывает его перед каждыми
+	// перевыборами CH. Возвращает измененные значения матрицы в любом порядке.
+	Tick(currentTime float64) []Change
+	// Trust возвращает доверие observerID к targetID
+	Trust(observerID, targetID int) float64
+	// Snapshot и Restore сохраняют собственное состояние модели для контрольных точек
+	// (nil - у модели нет состояния помимо матрицы менеджера)
+	Snapshot() []byte
+	Restore(data []byte) error
+}
+
+// Change - новое значение доверия Observer к Target
+type Change struct {
+	Observer, Target int
+	Value            float64
+}
+
+// UncertaintyEstimator - модель, которая оценивает неопределенность доверия по объему свидетельств
+type UncertaintyEstimator interface {
+	Uncertainty(observerID, targetID int) float64
+}
+
+// Названия встроенных моделей в SimulatorConfig.TrustModel
+const (
+	Simple         = "Simple"         // Экспоненциальное сглаживание наблюдений с весом AlphaTrust
+	Complex        = "Complex"        // Прямое, рекомендованное и историческое доверие с затуханием
+	TrustByDefault = "TrustByDefault" // Высокое доверие по умолчанию, штрафы только за доказанные сбросы
+)
+
+// registry - фабрики моделей по названию
+var registry = make(map[string]func() Model)
+
+// Register добавляет модель доверия под названием name. Вызывается из init() файла модели;
+// повторная регистрация названия - ошибка программы.
+func Register(name string, factory func() Model) {
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("модель доверия %q уже зарегистрирована", name))
+	}
+	registry[name] = factory
+}
+
+// Known сообщает, зарегистрирована ли модель с таким названием
+func Known(name string) bool {
+	_, ok := registry[name]
+	return ok
+}
+
+// Names возвращает названия зарегистрированных моделей в алфавитном порядке
+func Names() []string {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+func newModel(name string) (Model, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("неизвестная модель доверия: %q", name)
+	}
+	return factory(), nil
+}
+
+// base - общая часть моделей, хранящих доверие только в матрице менеджера
+type base struct {
+	tm *Manager
+}
+
+func (b *base) Init(tm *Manager) {
+	b.tm = tm
+}
+
+func (b *base) Tick(float64) []Change {
+	return nil
+}
+
+func (b *base) Trust(observerID, targetID int) float64 {
+	return b.tm.trustMatrix[observerID][targetID]
+}
+
+func (b *base) Snapshot() []byte {
+	return nil
+}
+
+func (b *base) Restore([]byte) error {
+	return nil
+}
@@ -0,0 +1,89 @@
+// Файл: trust/models.go
+package trust
+
+import "drone_trust_sim/models"
+
+func init() {
+	Register(Simple, func() Model { return &SimpleModel{} })
+	Register(Complex, func() Model { return &ComplexModel{} })
+	Register(TrustByDefault, func() Model { return &DefaultTrustModel{} })
+}
+
+// SimpleModel - экспоненциальное сглаживание: успех тянет доверие к 1, злонамеренный сброс
+// и сквозная потеря - к 0
+type SimpleModel struct {
+	base
+}
+
+func (m *SimpleModel) Update(observerID, targetID int, result models.InteractionResult, _ float64) {
+	tm := m.tm
+	alpha := tm.alpha(result)
+	oldTrust := tm.trustMatrix[observerID][targetID]
+	observation := 0.0
+	if result == models.InteractionSuccess {
+		observation = 1.0
+	}
+	newTrust := (1-alpha)*oldTrust + alpha*observation
+	tm.trustMatrix[observerID][targetID] = models.Clamp(newTrust, 0, 1)
+}
+
+// ComplexModel - комплексное доверие: взвешенная сумма прямого, рекомендованного
+// и исторического (с затуханием LambdaDecay) доверия
+type ComplexModel struct {
+	base
+}
+
+func (m *ComplexModel) Update(observerID, targetID int, result models.InteractionResult, currentTime float64) {
+	tm := m.tm
+	// 1. Рассчитываем прямой trust (T_d)
+	directTrust := calculateDirectTrust_unsafe(tm, observerID, targetID, result)
+
+	// 2. Рассчитываем рекомендованный trust (T_re)
+	recommendedTrust := calculateRecommendedTrust_unsafe(tm, observerID, targetID)
+
+	// 3. Рассчитываем исторический trust (T_h)
+	historicalTrust := calculateHistoricalTrust_unsafe(tm, observerID, targetID, currentTime)
+
+	// 4. Агрегируем все в комплексное доверие (T_total)
+	totalTrust := calculateTotalTrust(directTrust, recommendedTrust, historicalTrust)
+
+	tm.trustMatrix[observerID][targetID] = models.Clamp(totalTrust, 0, 1)
+	tm.lastUpdateTime[observerID][targetID] = currentTime
+}
+
+// DefaultTrustModel - доверие по умолчанию: снижается только за злонамеренные сбросы
+// и сквозные потери, после наказания медленно восстанавливается до InitialTrustValue.
+// Рекомендации не используются, так как они могут быть источником FP.
+type DefaultTrustModel struct {
+	base
+}
+
+func (m *DefaultTrustModel) Update(observerID, targetID int, result models.InteractionResult, _ float64) {
+	tm := m.tm
+	oldTrust := tm.trustMatrix[observerID][targetID]
+	newTrust := oldTrust
+
+	switch result {
+	case models.Failure_MaliciousDrop:
+		// Резко наказываем за доказанный злой умысел
+		// Можно использовать экспоненциальное наказание
+		newTrust = oldTrust * 0.5 // Каждый сброс режет доверие вдвое
+
+	case models.Failure_NoAck:
+		// Потеря где-то на маршруте - слабое свидетельство против каждого его узла
+		newTrust = oldTrust * (1 - 0.5*tm.cfg.AckEvidenceWeight)
+
+	case models.InteractionSuccess:
+		// Если узел был наказан, даем ему шанс медленно восстановиться
+		if oldTrust < tm.cfg.InitialTrustValue {
+			// Медленное восстановление, например, +0.01 за каждый успешный пакет
+			newTrust = models.Clamp(oldTrust+0.01, 0.0, tm.cfg.InitialTrustValue)
+		}
+		// Если доверие уже на максимуме, ничего не делаем
+
+	default:
+		// Для всех остальных случаев (нейтральные сбои, отсутствие взаимодействий)
+		// НЕ МЕНЯЕМ ДОВЕРИЕ ВОВСЕ.
+	}
+	tm.trustMatrix[observerID][targetID] = newTrust
+}
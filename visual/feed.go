@@ -0,0 +1,120 @@
+package visual
+
+import (
+	"drone_trust_sim/models"
+	"drone_trust_sim/simulator"
+)
+
+// maxEventsPerFrame - сколько передач и потерь пакетов попадает в один кадр; остальные
+// отбрасываются, чтобы браузер не захлебнулся при большой скорости воспроизведения
+const maxEventsPerFrame = 400
+
+// Сообщения, которые сервер отправляет странице (JSON, поле type различает вид)
+
+// initMsg - параметры поля, отправляются при подключении
+type initMsg struct {
+	Type      string  `json:"type"` // "init"
+	Algorithm string  `json:"algorithm"`
+	Width     float64 `json:"width"`
+	Height    float64 `json:"height"`
+	Radius    float64 `json:"radius"` // Радиус связи
+	Duration  float64 `json:"duration"`
+	Threshold float64 `json:"threshold"` // Порог доверия
+}
+
+// frameMsg - состояние роя и события с предыдущего кадра
+type frameMsg struct {
+	Type    string    `json:"type"` // "frame"
+	Time    float64   `json:"t"`
+	Running bool      `json:"running"`
+	Speed   float64   `json:"speed"`
+	PDR     float64   `json:"pdr"`
+	Nodes   []nodeMsg `json:"nodes"`
+	Hops    [][2]int  `json:"hops"` // Передачи по линиям: [от, кому]
+	Drops   []dropMsg `json:"drops"`
+	Elected []int     `json:"elected"` // Узлы, ставшие CH на выборах с предыдущего кадра
+}
+
+type nodeMsg struct {
+	ID        int     `json:"id"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+	Alive     bool    `json:"alive"`
+	Malicious bool    `json:"malicious"`
+	Station   bool    `json:"station"`
+	Head      bool    `json:"head"`
+	Cluster   int     `json:"cluster"`
+	Trust     float64 `json:"trust"` // Среднее входящее доверие
+	Energy    float64 `json:"energy"`
+	Sent      int     `json:"sent"`
+	Delivered int     `json:"delivered"`
+	Forwarded int     `json:"forwarded"`
+	Dropped   int     `json:"dropped"` // Сброшено самим узлом
+}
+
+type dropMsg struct {
+	Node   int    `json:"node"`
+	Reason string `json:"reason"`
+}
+
+// doneMsg - прогон завершен, итоговые метрики
+type doneMsg struct {
+	Type    string `json:"type"` // "done"
+	Metrics any    `json:"metrics"`
+}
+
+// feed - наблюдатель, накапливающий события пакетов и выборов между кадрами
+type feed struct {
+	simulator.BaseHook
+	hops    [][2]int
+	drops   []dropMsg
+	elected []int
+}
+
+func (f *feed) OnPacketForwarded(_ float64, from, to int, _ *models.Packet) {
+	if len(f.hops) < maxEventsPerFrame {
+		f.hops = append(f.hops, [2]int{from, to})
+	}
+}
+
+func (f *feed) OnPacketDropped(_ float64, nodeID int, _ *models.Packet, reason models.InteractionResult) {
+	if len(f.drops) < maxEventsPerFrame {
+		f.drops = append(f.drops, dropMsg{Node: nodeID, Reason: reason.String()})
+	}
+}
+
+func (f *feed) OnCHElected(_ float64, _ int, head *models.DroneNode) {
+	if head != nil {
+		f.elected = append(f.elected, head.ID)
+	}
+}
+
+// frame собирает кадр из текущего состояния симулятора и накопленных событий
+func (f *feed) frame(sim *simulator.Simulator) *frameMsg {
+	fr := &frameMsg{Type: "frame", Time: sim.Now(), Hops: f.hops, Drops: f.drops, Elected: f.elected}
+	f.hops, f.drops, f.elected = nil, nil, nil
+	for _, n := range sim.NodeViews() {
+		fr.Nodes = append(fr.Nodes, nodeMsg{
+			ID:        n.ID,
+			X:         n.Location.X,
+			Y:         n.Location.Y,
+			Z:         n.Location.Z,
+			Alive:     n.Status == models.NodeActive,
+			Malicious: n.IsMalicious,
+			Station:   n.IsGroundStation,
+			Head:      n.IsClusterHead,
+			Cluster:   n.ClusterID,
+			Trust:     sim.TrustManager.CalculateMeanIncomingTrust(n.ID),
+			Energy:    n.Energy,
+			Sent:      n.PacketsSent,
+			Delivered: n.PacketsDelivered,
+			Forwarded: n.PacketsForwarded,
+			Dropped:   n.PacketsDroppedByMe,
+		})
+	}
+	if sent := sim.Metrics.PacketsSent; sent > 0 {
+		fr.PDR = float64(sim.Metrics.PacketsDelivered) / float64(sent)
+	}
+	return fr
+}
@@ -0,0 +1,185 @@
+package visual
+
+import (
+	"drone_trust_sim/simulator"
+	_ "embed"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// frameInterval - период кадров в реальном времени
+const frameInterval = 100 * time.Millisecond
+
+// Server - локальный HTTP-сервер живой визуализации: отдает страницу и по WebSocket
+// передает ей кадры прогона. Прогон ведет одна горутина сервера; страница может
+// запустить его, приостановить и изменить скорость воспроизведения.
+type Server struct {
+	sim      *simulator.Simulator
+	feed     *feed
+	speed    float64 // Модельных секунд за секунду реального времени
+	commands chan string
+
+	mu      sync.Mutex
+	clients map[*client]bool
+	init    []byte // Последние сообщения init, frame и done: их получает новый клиент
+	last    []byte
+	done    []byte
+}
+
+// client - подключенная страница; кадры уходят через буфер, медленный клиент их пропускает
+type client struct {
+	ws   *wsConn
+	send chan []byte
+}
+
+// NewServer подключает визуализацию к еще не запущенному симулятору. speed - скорость
+// воспроизведения, модельных секунд за секунду реального времени.
+func NewServer(sim *simulator.Simulator, speed float64) *Server {
+	s := &Server{
+		sim:      sim,
+		feed:     &feed{},
+		speed:    speed,
+		commands: make(chan string, 16),
+		clients:  make(map[*client]bool),
+	}
+	sim.AddHook(s.feed)
+	return s
+}
+
+// ListenAndServe запускает прогон (на паузе до команды со страницы) и HTTP-сервер на addr
+func (s *Server) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/ws", s.handleWS)
+	go s.loop()
+	log.Printf("Визуализация: http://%s/", displayAddr(addr))
+	return http.ListenAndServe(addr, mux)
+}
+
+// displayAddr дополняет адрес вида ":8080" до открываемого в браузере
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexHTML)
+}
+
+// handleWS подключает страницу: отправляет ей текущее состояние и принимает команды
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	c := &client{ws: ws, send: make(chan []byte, 32)}
+	s.mu.Lock()
+	s.clients[c] = true
+	for _, msg := range [][]byte{s.init, s.last, s.done} {
+		if msg != nil {
+			c.send <- msg
+		}
+	}
+	s.mu.Unlock()
+
+	go func() {
+		for msg := range c.send {
+			if ws.WriteText(msg) != nil {
+				break
+			}
+		}
+		ws.Close()
+	}()
+	for {
+		cmd, err := ws.ReadText()
+		if err != nil {
+			break
+		}
+		s.commands <- cmd
+	}
+	s.mu.Lock()
+	delete(s.clients, c)
+	close(c.send)
+	s.mu.Unlock()
+}
+
+// broadcast рассылает сообщение всем клиентам и запоминает его для новых
+func (s *Server) broadcast(v any, slot *[]byte) {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Визуализация: %v", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*slot = msg
+	for c := range s.clients {
+		select {
+		case c.send <- msg:
+		default: // Клиент не успевает: кадр пропускается
+		}
+	}
+}
+
+// loop ведет прогон: каждые frameInterval продвигает модельное время на speed*frameInterval
+// и рассылает кадр. Команды страницы: play, pause и speed:<число>.
+func (s *Server) loop() {
+	cfg := s.sim.Cfg
+	s.broadcast(&initMsg{Type: "init", Algorithm: cfg.AlgorithmName, Width: cfg.AreaWidth, Height: cfg.AreaHeight,
+		Radius: cfg.CommunicationRadius, Duration: cfg.SimulationTime, Threshold: cfg.TrustThreshold}, &s.init)
+	running := false
+	s.sendFrame(running)
+
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case cmd := <-s.commands:
+			switch {
+			case cmd == "play":
+				running = !s.sim.Done()
+			case cmd == "pause":
+				running = false
+			case strings.HasPrefix(cmd, "speed:"):
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(cmd, "speed:"), 64); err == nil && v > 0 {
+					s.speed = v
+				}
+			}
+			s.sendFrame(running)
+
+		case <-ticker.C:
+			if !running {
+				continue
+			}
+			s.sim.RunUntil(s.sim.Now() + s.speed*frameInterval.Seconds())
+			if s.sim.Done() {
+				running = false
+			}
+			s.sendFrame(running)
+			if !running {
+				s.broadcast(&doneMsg{Type: "done", Metrics: s.sim.CurrentMetrics()}, &s.done)
+			}
+		}
+	}
+}
+
+func (s *Server) sendFrame(running bool) {
+	fr := s.feed.frame(s.sim)
+	fr.Running, fr.Speed = running, s.speed
+	s.broadcast(fr, &s.last)
+}
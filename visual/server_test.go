@@ -0,0 +1,151 @@
+package visual
+
+import (
+	"bufio"
+	"drone_trust_sim/config"
+	"drone_trust_sim/simulator"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var cfg *config.SimulatorConfig
+	for _, c := range config.GenerateExperimentConfigs() {
+		if c.NumDrones == 50 && c.AreaWidth == 400 && c.MaliciousRatio == 0.3 {
+			cfg = c
+			break
+		}
+	}
+	cfg.Seed = 42
+	sim, err := simulator.NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(sim, 10)
+	go s.loop()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/ws", s.handleWS)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// dialWS выполняет рукопожатие WebSocket с сервером
+func dialWS(t *testing.T, ts *httptest.Server) *wsConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	req, _ := http.NewRequest("GET", ts.URL+"/ws", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	resp, err := http.ReadResponse(rw.Reader, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("статус рукопожатия %d", resp.StatusCode)
+	}
+	// Пример ключа и ответа из RFC 6455, раздел 1.3
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	return &wsConn{conn: conn, rw: rw}
+}
+
+// sendMasked отправляет текстовое сообщение так, как это делает браузер: с маской
+func sendMasked(t *testing.T, c *wsConn, text string) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opText, 0x80 | byte(len(text))}
+	frame = append(frame, mask[:]...)
+	for i := range len(text) {
+		frame = append(frame, text[i]^mask[i%4])
+	}
+	c.rw.Write(frame)
+	if err := c.rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readMsg читает сообщение сервера и разбирает его в v; возвращает поле type
+func readMsg(t *testing.T, c *wsConn, v any) string {
+	t.Helper()
+	text, err := c.ReadText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var head struct{ Type string }
+	if err := json.Unmarshal([]byte(text), &head); err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		if err := json.Unmarshal([]byte(text), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return head.Type
+}
+
+// Новый клиент получает параметры поля и текущий кадр, а после play - кадры
+// продвигающегося прогона
+func TestServerFeed(t *testing.T) {
+	c := dialWS(t, newTestServer(t))
+
+	var init initMsg
+	if typ := readMsg(t, c, &init); typ != "init" || init.Width != 400 {
+		t.Fatalf("первое сообщение %q: %+v", typ, init)
+	}
+	var fr frameMsg
+	if typ := readMsg(t, c, &fr); typ != "frame" || fr.Running || len(fr.Nodes) != 50 {
+		t.Fatalf("второе сообщение %q: running=%v, узлов %d", typ, fr.Running, len(fr.Nodes))
+	}
+
+	sendMasked(t, c, "play")
+	for fr.Time == 0 {
+		fr = frameMsg{}
+		if typ := readMsg(t, c, &fr); typ != "frame" {
+			t.Fatalf("во время прогона пришло %q", typ)
+		}
+	}
+	if !fr.Running {
+		t.Error("кадр прогона не отмечен как выполняющийся")
+	}
+	sendMasked(t, c, "pause")
+}
+
+func TestServerRejectsPlainHTTP(t *testing.T) {
+	ts := newTestServer(t)
+	resp, err := http.Get(ts.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("запрос без Upgrade: статус %d", resp.StatusCode)
+	}
+	resp, err = http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("страница: статус %d, тип %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
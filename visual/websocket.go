@@ -0,0 +1,166 @@
+package visual
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Минимальная серверная часть WebSocket (RFC 6455): рукопожатие, отправка текстовых
+// кадров и чтение коротких управляющих сообщений браузера. Фрагментация и расширения
+// не поддерживаются - странице они не нужны.
+
+const (
+	wsGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessage = 1 << 16 // Ограничение размера сообщения от клиента, байт
+
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+var errUnsupportedFrame = errors.New("websocket: фрагментированные кадры не поддерживаются")
+
+// wsConn - установленное WebSocket-соединение. Запись безопасна из нескольких горутин.
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	mu   sync.Mutex
+}
+
+// upgrade выполняет рукопожатие WebSocket и забирает соединение у HTTP-сервера
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || !headerHasToken(r.Header, "Connection", "upgrade") {
+		http.Error(w, "ожидается WebSocket", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: запрос без Upgrade")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "нет Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: нет Sec-WebSocket-Key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "соединение не поддерживает WebSocket", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: ResponseWriter не поддерживает Hijack")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// headerHasToken сообщает, есть ли token в списке значений заголовка через запятую
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText отправляет текстовое сообщение одним кадром
+func (c *wsConn) WriteText(p []byte) error {
+	return c.writeFrame(opText, p)
+}
+
+func (c *wsConn) writeFrame(op byte, p []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	header := []byte{0x80 | op} // FIN и код операции; сервер кадры не маскирует
+	switch n := len(p); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	c.rw.Write(header)
+	c.rw.Write(p)
+	return c.rw.Flush()
+}
+
+// ReadText возвращает следующее текстовое сообщение клиента. На ping отвечает pong,
+// на закрытие - подтверждением закрытия и io.EOF.
+func (c *wsConn) ReadText() (string, error) {
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+			return "", err
+		}
+		fin, op := head[0]&0x80 != 0, head[0]&0x0F
+		masked := head[1]&0x80 != 0
+		n := uint64(head[1] & 0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+				return "", err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+				return "", err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if !fin || n > wsMaxMessage {
+			return "", errUnsupportedFrame
+		}
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+				return "", err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.rw, payload); err != nil {
+			return "", err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch op {
+		case opText:
+			return string(payload), nil
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return "", err
+			}
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return "", io.EOF
+		}
+	}
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}